@@ -0,0 +1,201 @@
+package fragment
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// ApplyMode determines how slices and maps are applied by `ApplyMasked`
+type ApplyMode int
+
+const (
+	// ApplyReplace replaces slices and maps of the destination with those of the source. If a nested fragment is defined for the field,
+	// the elements are applied to the elements of the destination at the same index or key.
+	ApplyReplace ApplyMode = iota
+	// ApplyMerge appends the elements of slices of the source to those of the destination, and sets the entries of maps of the source
+	// to those of the destination.
+	ApplyMerge
+)
+
+// ApplyMasked copies the fields included in the fragment from the source to the destination, which must be a non-nil pointer to a struct
+// of the fragment type. The source can be a struct, or a pointer to a struct, of any type, and its fields are matched by name. Fields are
+// converted using `typemeta.ConvertValue`, and nil source values reset the destination field to its zero value. If a nested fragment is
+// defined for a field, only the fields of that fragment are copied. Slices and maps are replaced, unless `ApplyMerge` is passed.
+func ApplyMasked(fragment Struct, dst, src interface{}, mode ...ApplyMode) error {
+	dstValue := reflect.ValueOf(dst)
+	if dstValue.Kind() != reflect.Ptr || dstValue.IsNil() {
+		return errors.New("expected non-nil pointer destination, but received " + typemeta.Get(dst).String())
+	}
+	applyMode := ApplyReplace
+	if len(mode) > 0 {
+		applyMode = mode[0]
+	}
+	if !fragment.IsValid() {
+		fragment = Struct{typeMeta: typemeta.StructOf(typemeta.Get(dstValue.Type()))}
+		if !fragment.IsValid() {
+			return errors.New("cannot apply to non-struct type " + dstValue.Type().String())
+		}
+	} else if fragment.IsUndefined() {
+		// ensure that the fields that are iterated are copied as a whole
+		fragment = fragment.EnsureDefined()
+	}
+	return applyMaskedValue(fragment, dstValue.Elem(), reflect.ValueOf(src), applyMode)
+}
+
+func applyMaskedValue(fragment Struct, dst reflect.Value, src reflect.Value, mode ApplyMode) error {
+	for src.Kind() == reflect.Interface && !src.IsNil() {
+		src = src.Elem()
+	}
+	if !src.IsValid() || ((src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface) && src.IsNil()) {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if fragment.IsUndefined() {
+		return applyValue(dst, src, mode)
+	}
+	if src.Kind() == reflect.Ptr {
+		return applyMaskedValue(fragment, dst, src.Elem(), mode)
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return applyMaskedValue(fragment, dst.Elem(), src, mode)
+	case reflect.Struct:
+		if src.Kind() != reflect.Struct {
+			return errors.New("cannot apply value of type " + src.Type().String() + " to struct type " + dst.Type().String())
+		}
+		return applyMaskedStruct(fragment, dst, src, mode)
+	case reflect.Slice:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			return errors.New("cannot apply value of type " + src.Type().String() + " to slice type " + dst.Type().String())
+		}
+		if mode == ApplyMerge {
+			merged := dst
+			for index := 0; index < src.Len(); index++ {
+				elem := reflect.New(dst.Type().Elem()).Elem()
+				if err := applyMaskedValue(fragment, elem, src.Index(index), mode); err != nil {
+					return err
+				}
+				merged = reflect.Append(merged, elem)
+			}
+			dst.Set(merged)
+			return nil
+		}
+		replaced := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for index := 0; index < src.Len(); index++ {
+			if index < dst.Len() {
+				replaced.Index(index).Set(dst.Index(index))
+			}
+			if err := applyMaskedValue(fragment, replaced.Index(index), src.Index(index), mode); err != nil {
+				return err
+			}
+		}
+		dst.Set(replaced)
+		return nil
+	case reflect.Array:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			return errors.New("cannot apply value of type " + src.Type().String() + " to array type " + dst.Type().String())
+		}
+		for index := 0; index < src.Len() && index < dst.Len(); index++ {
+			if err := applyMaskedValue(fragment, dst.Index(index), src.Index(index), mode); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if src.Kind() != reflect.Map {
+			return errors.New("cannot apply value of type " + src.Type().String() + " to map type " + dst.Type().String())
+		}
+		applied := dst
+		if mode == ApplyReplace || dst.IsNil() {
+			applied = reflect.MakeMapWithSize(dst.Type(), src.Len())
+		}
+		mapIter := src.MapRange()
+		for mapIter.Next() {
+			key, err := typemeta.ConvertValue(mapIter.Key(), dst.Type().Key())
+			if err != nil {
+				return err
+			}
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if existing := dst.MapIndex(key); existing.IsValid() {
+				elem.Set(existing)
+			}
+			if err := applyMaskedValue(fragment, elem, mapIter.Value(), mode); err != nil {
+				return err
+			}
+			applied.SetMapIndex(key, elem)
+		}
+		dst.Set(applied)
+		return nil
+	default:
+		return applyValue(dst, src, mode)
+	}
+}
+
+func applyMaskedStruct(fragment Struct, dst reflect.Value, src reflect.Value, mode ApplyMode) error {
+	structTypeMeta := fragment.TypeMeta()
+	if dst.Type() != structTypeMeta.Type() {
+		return errors.New("type of destination and fragment do not match: " + dst.Type().String() + " vs. " + structTypeMeta.String())
+	}
+	var srcTypeMeta *typemeta.Struct
+	if src.Type() != dst.Type() {
+		srcTypeMeta = typemeta.StructOf(typemeta.Get(src.Type()))
+	}
+	var err error
+	fragment.FindField(func(field StructField) bool {
+		dstField := dst.Field(field.Index)
+		if !dstField.CanSet() {
+			return false
+		}
+		var srcField reflect.Value
+		if srcTypeMeta == nil {
+			srcField = src.Field(field.Index)
+		} else if srcStructField := srcTypeMeta.FieldByName(field.Name); srcStructField != nil {
+			srcField = src.Field(srcStructField.Index)
+		} else if srcStructField := srcTypeMeta.FieldByName(field.JSONName); srcStructField != nil {
+			srcField = src.Field(srcStructField.Index)
+		} else {
+			// the field does not exist in the source
+			return false
+		}
+		if applyErr := applyMaskedValue(field.Fragment, dstField, srcField, mode); applyErr != nil {
+			err = NewError(applyErr).Register(field.Name)
+			return true
+		}
+		return false
+	})
+	return err
+}
+
+// applyValue applies a source value as a whole to the destination
+func applyValue(dst reflect.Value, src reflect.Value, mode ApplyMode) error {
+	converted, err := typemeta.ConvertValue(src, dst.Type())
+	if err != nil {
+		return err
+	}
+	if mode == ApplyMerge {
+		switch dst.Kind() {
+		case reflect.Slice:
+			dst.Set(reflect.AppendSlice(dst, converted))
+			return nil
+		case reflect.Map:
+			if converted.IsNil() {
+				return nil
+			}
+			if dst.IsNil() {
+				dst.Set(reflect.MakeMapWithSize(dst.Type(), converted.Len()))
+			}
+			mapIter := converted.MapRange()
+			for mapIter.Next() {
+				dst.SetMapIndex(mapIter.Key(), mapIter.Value())
+			}
+			return nil
+		}
+	}
+	dst.Set(converted)
+	return nil
+}
@@ -0,0 +1,97 @@
+package fragment
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestApplyMasked(t *testing.T) {
+	type Address struct {
+		City   string `json:"city"`
+		Street string `json:"street"`
+	}
+	type User struct {
+		Name      string            `json:"name"`
+		Age       int               `json:"age"`
+		Address   *Address          `json:"address"`
+		Tags      []string          `json:"tags"`
+		Labels    map[string]string `json:"labels"`
+		Addresses []Address         `json:"addresses"`
+	}
+	newUser := func() User {
+		return User{
+			Name:      "Ada",
+			Age:       36,
+			Address:   &Address{City: "London", Street: "Baker Street"},
+			Tags:      []string{"a"},
+			Labels:    map[string]string{"x": "1"},
+			Addresses: []Address{{City: "Paris", Street: "Rue"}},
+		}
+	}
+	t.Run("copies fields selected by the fragment", func(t *testing.T) {
+		dst := newUser()
+		src := User{Name: "Grace", Age: 85, Address: &Address{City: "Arlington"}, Tags: []string{"b"}, Addresses: []Address{{City: "Rome"}, {City: "Oslo"}}}
+		fragment, err := ParseStruct(User{}, "name, address { city }, tags, addresses { city }")
+		if err != nil {
+			t.Error("did not expect `ParseStruct` to return error: " + err.Error())
+			return
+		}
+		if err := ApplyMasked(fragment, &dst, src); err != nil {
+			t.Error("did not expect `ApplyMasked` to return error: " + err.Error())
+			return
+		}
+		if dst.Name != "Grace" || dst.Age != 36 {
+			t.Error("expected only selected immediate fields to be copied")
+		}
+		if dst.Address.City != "Arlington" || dst.Address.Street != "Baker Street" {
+			t.Error("expected only selected nested fields to be copied")
+		}
+		if fmt.Sprint(dst.Tags) != "[b]" {
+			t.Error("expected slice to be replaced, but received " + fmt.Sprint(dst.Tags))
+		}
+		if fmt.Sprint(dst.Addresses) != "[{Rome Rue} {Oslo }]" {
+			t.Error("expected slice elements to be applied by index, but received " + fmt.Sprint(dst.Addresses))
+		}
+		if dst.Labels["x"] != "1" {
+			t.Error("expected excluded map to be kept")
+		}
+	})
+	t.Run("merges slices and maps", func(t *testing.T) {
+		dst := newUser()
+		src := User{Tags: []string{"b"}, Labels: map[string]string{"y": "2"}}
+		if err := ApplyMasked(NewEmptyStruct(User{}).AddByName("Tags", "Labels"), &dst, &src, ApplyMerge); err != nil {
+			t.Error("did not expect `ApplyMasked` to return error: " + err.Error())
+			return
+		}
+		if fmt.Sprint(dst.Tags) != "[a b]" {
+			t.Error("expected slice to be merged, but received " + fmt.Sprint(dst.Tags))
+		}
+		if dst.Labels["x"] != "1" || dst.Labels["y"] != "2" {
+			t.Error("expected map to be merged, but received " + fmt.Sprint(dst.Labels))
+		}
+	})
+	t.Run("converts fields of other types", func(t *testing.T) {
+		type UserPatch struct {
+			Name    *string  `json:"name"`
+			Age     *int     `json:"age"`
+			Address *Address `json:"address"`
+		}
+		dst := newUser()
+		name := "Grace"
+		if err := ApplyMasked(NewEmptyStruct(User{}).AddByName("name", "age", "address"), &dst, UserPatch{Name: &name}); err != nil {
+			t.Error("did not expect `ApplyMasked` to return error: " + err.Error())
+			return
+		}
+		if dst.Name != "Grace" {
+			t.Error("expected pointer value to be converted")
+		}
+		if dst.Age != 0 || dst.Address != nil {
+			t.Error("expected nil values to reset the destination fields")
+		}
+	})
+	t.Run("rejects non-pointer destinations", func(t *testing.T) {
+		if err := ApplyMasked(NewStruct(User{}), newUser(), newUser()); err == nil {
+			t.Error("expected `ApplyMasked` to return error for non-pointer destination")
+		}
+	})
+}
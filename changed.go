@@ -0,0 +1,94 @@
+package fragment
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// Changed compares two values of the same struct type, or pointers to such values, and returns the fragment of the fields whose values differ,
+// e.g. `{ name, address { city } }`. Fields of structs and pointers to structs are compared recursively, while other fields are compared
+// as a whole using an `Equal` method if one is defined for the type (such as for `time.Time`), and otherwise using `reflect.DeepEqual`.
+// If a fragment is passed, only the fields included in that fragment are compared. If no fields differ, an empty fragment is returned.
+func Changed(a, b interface{}, limit ...Struct) (Struct, error) {
+	aValue := reflect.ValueOf(a)
+	bValue := reflect.ValueOf(b)
+	if !aValue.IsValid() || !bValue.IsValid() {
+		return Struct{}, errors.New("cannot compare nil values")
+	} else if aValue.Type() != bValue.Type() {
+		return Struct{}, errors.New("cannot compare values of different types: " + aValue.Type().String() + " vs. " + bValue.Type().String())
+	}
+	structTypeMeta := typemeta.StructOf(typemeta.NonPtr(typemeta.Get(aValue.Type())))
+	if structTypeMeta == nil || structTypeMeta.Primitive() {
+		return Struct{}, errors.New("cannot compare non-struct type " + aValue.Type().String())
+	}
+	fragment := Struct{typeMeta: structTypeMeta}
+	for _, limit := range limit {
+		if !limit.IsValid() {
+			continue
+		} else if limit.TypeMeta().Type() != structTypeMeta.Type() {
+			return Struct{}, errors.New("type mismatch between limiting fragment and compared values: " + limit.TypeMeta().String() + " vs. " + structTypeMeta.String())
+		}
+		fragment = limit
+	}
+	for aValue.Kind() == reflect.Ptr {
+		if aValue.IsNil() || bValue.IsNil() {
+			if aValue.IsNil() && bValue.IsNil() {
+				return NewEmptyStruct(structTypeMeta), nil
+			}
+			return fragment.EnsureDefined(), nil
+		}
+		aValue = aValue.Elem()
+		bValue = bValue.Elem()
+	}
+	return changed(fragment, aValue, bValue), nil
+}
+
+func changed(fragment Struct, a, b reflect.Value) Struct {
+	result := NewEmptyStruct(fragment.TypeMeta())
+	fragment.IterateExplicitFields(func(field StructField) {
+		aField := a.Field(field.Index)
+		bField := b.Field(field.Index)
+		if !aField.CanInterface() {
+			return
+		}
+		if fieldStructTypeMeta, ok := typemeta.NonPtr(field.TypeMeta).(*typemeta.Struct); ok && field.Fragment.IsValid() && !isNullableType(fieldStructTypeMeta.Type()) {
+			for aField.Kind() == reflect.Ptr {
+				if aField.IsNil() || bField.IsNil() {
+					break
+				}
+				aField = aField.Elem()
+				bField = bField.Elem()
+			}
+			if aField.Kind() == reflect.Struct {
+				fieldFragment := field.Fragment
+				if fieldFragment.IsUndefined() {
+					fieldFragment = Struct{typeMeta: fieldStructTypeMeta}
+				}
+				if fieldChanged := changed(fieldFragment, aField, bField); !fieldChanged.IsEmpty() {
+					result = result.Set(field.Index, fieldChanged)
+				}
+				return
+			}
+		}
+		if !valuesEqual(aField, bField) {
+			result = result.Add(field.Index)
+		}
+	})
+	return result
+}
+
+// valuesEqual returns whether two values of the same type are equal, using an `Equal` method if one is defined for the type, and otherwise using `reflect.DeepEqual`
+func valuesEqual(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Ptr && (a.IsNil() || b.IsNil()) {
+		return a.IsNil() && b.IsNil()
+	}
+	if equal := a.MethodByName("Equal"); equal.IsValid() {
+		equalType := equal.Type()
+		if equalType.NumIn() == 1 && equalType.In(0) == a.Type() && equalType.NumOut() == 1 && equalType.Out(0).Kind() == reflect.Bool {
+			return equal.Call([]reflect.Value{b})[0].Bool()
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,69 @@
+package fragment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChanged(t *testing.T) {
+	type Address struct {
+		City   string `json:"city"`
+		Street string `json:"street"`
+	}
+	type User struct {
+		Name      string    `json:"name"`
+		Age       int       `json:"age"`
+		Address   *Address  `json:"address"`
+		Tags      []string  `json:"tags"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	createdAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := User{Name: "Ada", Age: 36, Address: &Address{City: "London", Street: "Baker Street"}, Tags: []string{"a"}, CreatedAt: createdAt}
+	t.Run("returns the fragment of changed fields", func(t *testing.T) {
+		b := a
+		b.Name = "Grace"
+		b.Address = &Address{City: "Arlington", Street: "Baker Street"}
+		b.Tags = []string{"a"}
+		b.CreatedAt = createdAt.In(time.FixedZone("CET", 3600))
+		fragment, err := Changed(a, b)
+		if err != nil {
+			t.Error("did not expect `Changed` to return error: " + err.Error())
+			return
+		}
+		if fragment.Expr() != "{ Name, Address { City } }" {
+			t.Error("expected changed fragment `{ Name, Address { City } }`, but received " + fragment.Expr())
+		}
+		if fragment.JSONExpr() != "{ name, address { city } }" {
+			t.Error("expected changed JSON fragment `{ name, address { city } }`, but received " + fragment.JSONExpr())
+		}
+	})
+	t.Run("limits compared fields", func(t *testing.T) {
+		b := a
+		b.Name = "Grace"
+		b.Age = 85
+		b.Address = nil
+		fragment, err := Changed(&a, &b, NewEmptyStruct(User{}).AddByName("age", "address"))
+		if err != nil {
+			t.Error("did not expect `Changed` to return error: " + err.Error())
+			return
+		}
+		if fragment.Expr() != "{ Age, Address }" {
+			t.Error("expected changed fragment `{ Age, Address }`, but received " + fragment.Expr())
+		}
+	})
+	t.Run("returns an empty fragment for equal values", func(t *testing.T) {
+		b := a
+		b.Address = &Address{City: "London", Street: "Baker Street"}
+		fragment, err := Changed(a, b)
+		if err != nil {
+			t.Error("did not expect `Changed` to return error: " + err.Error())
+			return
+		}
+		if !fragment.IsEmpty() {
+			t.Error("expected changed fragment to be empty, but received " + fragment.Expr())
+		}
+		if _, err := Changed(a, &b); err == nil {
+			t.Error("expected `Changed` to return error for values of different types")
+		}
+	})
+}
@@ -0,0 +1,16 @@
+// Command fragment filters and inspects JSON using fragment expressions, such as "id, user { name }", which are the expressions of the `fields`
+// parameters parsed by `fragment.ParseUnstructured`.
+//
+//	fragment pick [-indent] EXPR [FILE...]   picks the fields of JSON documents and NDJSON streams
+//	fragment fmt [-compact] [EXPR]          canonicalizes and pretty-prints an expression, read from stdin if not specified
+//	fragment paths EXPR                     lists the leaf paths of an expression
+//	fragment diff A B                       compares the leaf paths of two expressions, and exits with status 1 if they differ
+package main
+
+import (
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
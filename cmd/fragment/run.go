@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+const usage = `usage:
+  fragment pick [-indent] EXPR [FILE...]
+  fragment fmt [-compact] [EXPR]
+  fragment paths EXPR
+  fragment diff A B
+`
+
+// run runs a command and returns the exit status, which is 2 for errors, and 1 for `diff` if the expressions differ
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var err error
+	status := 0
+	switch args[0] {
+	case "pick":
+		err = runPick(args[1:], stdin, stdout, stderr)
+	case "fmt":
+		err = runFmt(args[1:], stdin, stdout, stderr)
+	case "paths":
+		err = runPaths(args[1:], stdout, stderr)
+	case "diff":
+		status, err = runDiff(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+	default:
+		err = errors.New("unknown command \"" + args[0] + "\"\n" + usage)
+	}
+	if err == flag.ErrHelp {
+		return 2
+	} else if err != nil {
+		fmt.Fprintln(stderr, "fragment: "+err.Error())
+		return 2
+	}
+	return status
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet("fragment "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+// runPick picks the fields of each JSON value of the files, or of stdin, and writes the picked values on separate lines
+func runPick(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("pick", stderr)
+	indent := flags.Bool("indent", false, "indent the picked JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	} else if flags.NArg() == 0 {
+		return errors.New("expected expression")
+	}
+	unstructured, err := fragment.ParseUnstructured(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	readers := []io.Reader{stdin}
+	if flags.NArg() > 1 {
+		readers = nil
+		for _, fileName := range flags.Args()[1:] {
+			file, err := os.Open(fileName)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			readers = append(readers, file)
+		}
+	}
+	decoder := json.NewDecoder(io.MultiReader(readers...))
+	decoder.UseNumber()
+	encoder := json.NewEncoder(stdout)
+	encoder.SetEscapeHTML(false)
+	if *indent {
+		encoder.SetIndent("", "  ")
+	}
+	for {
+		var value interface{}
+		if err := decoder.Decode(&value); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		picked, err := fragment.PickJSON(unstructured, value)
+		if err != nil {
+			return err
+		}
+		if err := encoder.Encode(picked); err != nil {
+			return err
+		}
+	}
+}
+
+// runFmt writes the canonical expression of an expression, where the fields are sorted by name, on multiple indented lines unless compact
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("fmt", stderr)
+	compact := flags.Bool("compact", false, "write the expression on a single line")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	expr := strings.Join(flags.Args(), " ")
+	if flags.NArg() == 0 {
+		bytes, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		expr = string(bytes)
+	}
+	unstructured, err := fragment.ParseUnstructured(expr)
+	if err != nil {
+		return err
+	} else if *compact {
+		_, err = fmt.Fprintln(stdout, unstructured.Expr())
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, formatExpr(unstructured, ""))
+	return err
+}
+
+// formatExpr returns the expression of a fragment with a field on each line, indented by the depth of the field
+func formatExpr(unstructured fragment.Unstructured, indent string) string {
+	if unstructured.IsUndefined() {
+		return ""
+	}
+	fieldNames, fieldFragments := sortedFields(unstructured)
+	if len(fieldNames) == 0 {
+		return "{}"
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for i, fieldName := range fieldNames {
+		b.WriteString(indent + "  " + fieldName)
+		if fieldFragment, ok := fieldFragments[i].(fragment.Unstructured); ok && !fieldFragment.IsUndefined() {
+			b.WriteString(" " + formatExpr(fieldFragment, indent+"  "))
+		}
+		if i < len(fieldNames)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// runPaths writes the leaf paths of an expression on separate lines
+func runPaths(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("paths", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	} else if flags.NArg() != 1 {
+		return errors.New("expected one expression")
+	}
+	unstructured, err := fragment.ParseUnstructured(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, path := range leafPaths(unstructured, "") {
+		fmt.Fprintln(stdout, path)
+	}
+	return nil
+}
+
+// runDiff writes the leaf paths that are only in the first expression prefixed by "-", and those that are only in the second prefixed by "+"
+func runDiff(args []string, stdout, stderr io.Writer) (int, error) {
+	flags := newFlagSet("diff", stderr)
+	if err := flags.Parse(args); err != nil {
+		return 0, err
+	} else if flags.NArg() != 2 {
+		return 0, errors.New("expected two expressions")
+	}
+	a, err := fragment.ParseUnstructured(flags.Arg(0))
+	if err != nil {
+		return 0, err
+	}
+	b, err := fragment.ParseUnstructured(flags.Arg(1))
+	if err != nil {
+		return 0, err
+	}
+	aPaths, bPaths := pathSet(leafPaths(a, "")), pathSet(leafPaths(b, ""))
+	var lines []string
+	for path := range aPaths {
+		if !bPaths[path] {
+			lines = append(lines, "- "+path)
+		}
+	}
+	for path := range bPaths {
+		if !aPaths[path] {
+			lines = append(lines, "+ "+path)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i][2:] < lines[j][2:] || (lines[i][2:] == lines[j][2:] && lines[i] < lines[j])
+	})
+	for _, line := range lines {
+		fmt.Fprintln(stdout, line)
+	}
+	if len(lines) > 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// leafPaths returns the paths of the fields of a fragment that do not have fragments, e.g. "id" and "user.name" for "id, user { name }"
+func leafPaths(unstructured fragment.Unstructured, prefix string) []string {
+	var paths []string
+	fieldNames, fieldFragments := sortedFields(unstructured)
+	for i, fieldName := range fieldNames {
+		if fieldFragment, ok := fieldFragments[i].(fragment.Unstructured); ok && !fieldFragment.IsUndefinedOrEmpty() {
+			paths = append(paths, leafPaths(fieldFragment, prefix+fieldName+".")...)
+		} else {
+			paths = append(paths, prefix+fieldName)
+		}
+	}
+	return paths
+}
+
+func sortedFields(unstructured fragment.Unstructured) ([]string, []fragment.Fragment) {
+	var fieldNames []string
+	unstructured.IterateFields(func(fieldName string, _ fragment.Fragment) {
+		fieldNames = append(fieldNames, fieldName)
+	})
+	sort.Strings(fieldNames)
+	fieldFragments := make([]fragment.Fragment, len(fieldNames))
+	for i, fieldName := range fieldNames {
+		fieldFragments[i] = unstructured.Field(fieldName)
+	}
+	return fieldNames, fieldFragments
+}
+
+func pathSet(paths []string) map[string]bool {
+	set := map[string]bool{}
+	for _, path := range paths {
+		set[path] = true
+	}
+	return set
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+func TestRun(t *testing.T) {
+	runWith := func(stdin string, args ...string) (string, string, int) {
+		var stdout, stderr bytes.Buffer
+		status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String(), stderr.String(), status
+	}
+	t.Run("picks JSON documents and NDJSON streams", func(t *testing.T) {
+		stdin := `{"id": 1, "name": "A", "user": {"name": "B", "email": "b@example.com"}}
+{"id": 2.50, "user": null}
+[{"id": 3, "extra": true}]
+`
+		stdout, stderr, status := runWith(stdin, "pick", "{ id, user { name } }")
+		if status != 0 {
+			t.Error("did not expect `pick` to fail: " + stderr)
+		}
+		expected := `{"id":1,"user":{"name":"B"}}
+{"id":2.50,"user":null}
+[{"id":3}]
+`
+		if stdout != expected {
+			t.Error("expected `pick` to write " + expected + ", got " + stdout)
+		}
+		stdout, _, _ = runWith(`{"id": 1, "name": "A"}`, "pick", "-indent", "id")
+		if expected := "{\n  \"id\": 1\n}\n"; stdout != expected {
+			t.Error("expected `pick -indent` to write " + expected + ", got " + stdout)
+		}
+		if _, _, status := runWith(`{"id": `, "pick", "id"); status != 2 {
+			t.Error("expected `pick` to fail for invalid JSON")
+		}
+	})
+	t.Run("formats expressions", func(t *testing.T) {
+		stdout, stderr, status := runWith("", "fmt", "user { name, id }, c, a {}")
+		if status != 0 {
+			t.Error("did not expect `fmt` to fail: " + stderr)
+		}
+		expected := "{\n  a {},\n  c,\n  user {\n    id,\n    name\n  }\n}\n"
+		if stdout != expected {
+			t.Error("expected `fmt` to write " + expected + ", got " + stdout)
+		}
+		if unstructured, err := fragment.ParseUnstructured(stdout); err != nil {
+			t.Error("did not expect formatted expression to return error: " + err.Error())
+		} else if expr := unstructured.Expr(); expr != "{ a {}, c, user { id, name } }" {
+			t.Error("expected formatted expression to be parsed as the expression, got " + expr)
+		}
+		stdout, _, _ = runWith("c, b { e, d }, a", "fmt", "-compact")
+		if expected := "{ a, b { d, e }, c }\n"; stdout != expected {
+			t.Error("expected `fmt -compact` to write " + expected + ", got " + stdout)
+		}
+		if _, _, status := runWith("", "fmt", "a {"); status != 2 {
+			t.Error("expected `fmt` to fail for invalid expression")
+		}
+	})
+	t.Run("lists leaf paths", func(t *testing.T) {
+		stdout, _, _ := runWith("", "paths", "user { profile { bio }, name }, id")
+		if expected := "id\nuser.name\nuser.profile.bio\n"; stdout != expected {
+			t.Error("expected `paths` to write " + expected + ", got " + stdout)
+		}
+	})
+	t.Run("compares expressions", func(t *testing.T) {
+		stdout, _, status := runWith("", "diff", "id, user { name, email }", "id, user { name, profile { bio } }, createdAt")
+		if expected := "+ createdAt\n- user.email\n+ user.profile.bio\n"; stdout != expected || status != 1 {
+			t.Error("expected `diff` to write " + expected + ", got " + stdout)
+		}
+		if stdout, _, status := runWith("", "diff", "b, a", "{ a, b }"); stdout != "" || status != 0 {
+			t.Error("did not expect `diff` to report differences of equivalent expressions")
+		}
+	})
+	t.Run("fails for blank and empty-brace expressions", func(t *testing.T) {
+		for _, args := range [][]string{{"fmt"}, {"fmt", "a { }"}, {"paths", "a { }"}, {"paths", " "}, {"pick", " "}, {"diff", "a", "{ }"}} {
+			_, stderr, status := runWith("\n", args...)
+			if status != 2 || !strings.HasPrefix(stderr, "fragment: ") {
+				t.Error("expected `" + strings.Join(args, " ") + "` to fail with an error, but received status " + strconv.Itoa(status) + ": " + stderr)
+			}
+		}
+	})
+	t.Run("fails for unknown commands", func(t *testing.T) {
+		if _, stderr, status := runWith("", "unknown"); status != 2 || !strings.Contains(stderr, "unknown command") {
+			t.Error("expected `run` to fail for unknown command")
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// builder is a struct type to generate a builder for
+type builder struct {
+	typeName string
+	fields   []builderField
+}
+
+// builderField is a field of a struct type for which a method of the builder is generated
+type builderField struct {
+	name   string
+	method string
+	// elemTypeName is the name of the struct type of the field, or of the elements of the field, if a builder is generated for it
+	elemTypeName string
+}
+
+// generateDir generates the builders of the struct types of the package in a directory, excluding tests and generated files
+func generateDir(dir string, typeNames []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	} else if len(pkgs) != 1 {
+		return nil, errors.New("expected one package in \"" + dir + "\"")
+	}
+	for _, pkg := range pkgs {
+		fileNames := make([]string, 0, len(pkg.Files))
+		for fileName := range pkg.Files {
+			fileNames = append(fileNames, fileName)
+		}
+		sort.Strings(fileNames)
+		files := make([]*ast.File, len(fileNames))
+		for i, fileName := range fileNames {
+			files[i] = pkg.Files[fileName]
+		}
+		return generate(pkg.Name, files, typeNames)
+	}
+	return nil, nil
+}
+
+// generate generates the builders of struct types declared in files of a package. If no type names are specified, builders are generated for
+// all exported struct types.
+func generate(packageName string, files []*ast.File, typeNames []string) ([]byte, error) {
+	structTypes := map[string]*ast.StructType{}
+	var declaredTypeNames []string
+	for _, file := range files {
+		if isGenerated(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+					structTypes[typeSpec.Name.Name] = structType
+					declaredTypeNames = append(declaredTypeNames, typeSpec.Name.Name)
+				}
+			}
+		}
+	}
+	if len(typeNames) == 0 {
+		for _, typeName := range declaredTypeNames {
+			if ast.IsExported(typeName) {
+				typeNames = append(typeNames, typeName)
+			}
+		}
+		if len(typeNames) == 0 {
+			return nil, errors.New("no exported struct types in package \"" + packageName + "\"")
+		}
+	}
+	generated := map[string]bool{}
+	for _, typeName := range typeNames {
+		if structTypes[typeName] == nil {
+			return nil, errors.New("no struct type \"" + typeName + "\" in package \"" + packageName + "\"")
+		}
+		generated[typeName] = true
+	}
+	builders := make([]builder, len(typeNames))
+	for i, typeName := range typeNames {
+		b, err := newBuilder(typeName, structTypes[typeName], generated)
+		if err != nil {
+			return nil, err
+		}
+		builders[i] = b
+	}
+	var source bytes.Buffer
+	source.WriteString("// Code generated by fragmentgen. DO NOT EDIT.\n\npackage " + packageName + "\n\n")
+	source.WriteString("import fragment \"github.com/ludvigalden/go-fragment\"\n")
+	for _, b := range builders {
+		b.write(&source)
+	}
+	return format.Source(source.Bytes())
+}
+
+func newBuilder(typeName string, structType *ast.StructType, generated map[string]bool) (builder, error) {
+	b := builder{typeName: typeName}
+	methods := map[string]bool{"Struct": true}
+	for _, field := range structType.Fields.List {
+		if isExcluded(field) {
+			// fields that are never presented by `fragment.PickJSON`, or that are hidden from fragments, cannot be selected
+			continue
+		}
+		names := make([]string, len(field.Names))
+		for i, name := range field.Names {
+			names[i] = name.Name
+		}
+		if len(names) == 0 {
+			// embedded fields are named by their types
+			names = []string{typeIdentName(field.Type)}
+		}
+		elemTypeName := ""
+		if ident, ok := elemType(field.Type).(*ast.Ident); ok && generated[ident.Name] {
+			elemTypeName = ident.Name
+		}
+		for _, name := range names {
+			if !ast.IsExported(name) {
+				continue
+			}
+			method := name
+			if methods[method] {
+				method += "Field"
+				if methods[method] {
+					return b, errors.New("cannot generate method for field \"" + typeName + "." + name + "\"")
+				}
+			}
+			methods[method] = true
+			b.fields = append(b.fields, builderField{name: name, method: method, elemTypeName: elemTypeName})
+		}
+	}
+	return b, nil
+}
+
+func (b builder) write(w *bytes.Buffer) {
+	builderName := b.typeName + "FragmentBuilder"
+	w.WriteString("\n// " + builderName + " builds fragments of `" + b.typeName + "` with field names that are checked at compile time\n")
+	w.WriteString("type " + builderName + " struct {\n\tf fragment.Struct\n}\n")
+	w.WriteString("\n// " + b.typeName + "Fragment returns a builder of a fragment of `" + b.typeName + "` without any fields\n")
+	w.WriteString("func " + b.typeName + "Fragment() " + builderName + " {\n\treturn " + builderName + "{f: fragment.NewEmptyStruct(" + b.typeName + "{})}\n}\n")
+	w.WriteString("\n// Struct returns the fragment\n")
+	w.WriteString("func (b " + builderName + ") Struct() fragment.Struct {\n\treturn b.f\n}\n")
+	for _, field := range b.fields {
+		if field.elemTypeName == "" {
+			w.WriteString("\n// " + field.method + " adds the field `" + field.name + "`\n")
+			w.WriteString("func (b " + builderName + ") " + field.method + "() " + builderName + " {\n")
+			w.WriteString("\treturn " + builderName + "{f: b.f.AddByName(\"" + field.name + "\")}\n}\n")
+			continue
+		}
+		w.WriteString("\n// " + field.method + " adds the field `" + field.name + "`. If fragments are specified, the fragment of the field is set to the fields of the fragments.\n")
+		w.WriteString("func (b " + builderName + ") " + field.method + "(fragments ..." + field.elemTypeName + "FragmentBuilder) " + builderName + " {\n")
+		w.WriteString("\tif len(fragments) == 0 {\n\t\treturn " + builderName + "{f: b.f.AddByName(\"" + field.name + "\")}\n\t}\n")
+		w.WriteString("\tf := fragments[0].f\n\tfor _, other := range fragments[1:] {\n\t\tf = f.AssignStruct(other.f)\n\t}\n")
+		w.WriteString("\treturn " + builderName + "{f: b.f.SetByName(\"" + field.name + "\", f)}\n}\n")
+	}
+	// the field names are passed by name to the fragment, so they are referenced to fail compilation if the fields no longer exist
+	if len(b.fields) > 0 {
+		w.WriteString("\nfunc _() {\n\tvar v " + b.typeName + "\n")
+		for _, field := range b.fields {
+			w.WriteString("\t_ = v." + field.name + "\n")
+		}
+		w.WriteString("}\n")
+	}
+}
+
+// elemType returns the type of a pointer, slice, or array type, or the type itself
+func elemType(expr ast.Expr) ast.Expr {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return elemType(expr.X)
+	case *ast.ArrayType:
+		return elemType(expr.Elt)
+	}
+	return expr
+}
+
+// typeIdentName returns the name of a named type, or of the named type of a pointer, e.g. "User" for `*User` and `models.User`
+func typeIdentName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.StarExpr:
+		return typeIdentName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	}
+	return ""
+}
+
+// isExcluded returns whether a field is excluded from JSON by the tag `json:"-"`, or hidden from fragments by the tag `fragment:"-"`
+func isExcluded(field *ast.Field) bool {
+	if field.Tag == nil {
+		return false
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return false
+	}
+	fragmentTagName := strings.Split(reflect.StructTag(tag).Get("fragment"), ",")[0]
+	return reflect.StructTag(tag).Get("json") == "-" || fragmentTagName == "-"
+}
+
+// isGenerated returns whether a file is generated, i.e. whether it has a comment "// Code generated ... DO NOT EDIT." before the package clause
+func isGenerated(file *ast.File) bool {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			return false
+		}
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, "// Code generated ") && strings.HasSuffix(comment.Text, " DO NOT EDIT.") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const modelsSource = `package models
+
+type Profile struct {
+	Bio string
+}
+
+type User struct {
+	Name, Email string
+	Password    string ` + "`json:\"-\"`" + `
+	Token       string ` + "`json:\"token\" fragment:\"-\"`" + `
+	Profile     *Profile
+	Friends     []*User
+	Struct      string
+	secret      string
+}
+
+type internal struct {
+	Value string
+}
+`
+
+func TestGenerate(t *testing.T) {
+	fset := token.NewFileSet()
+	parse := func(fileName string, source string) *ast.File {
+		file, err := parser.ParseFile(fset, fileName, source, parser.ParseComments)
+		if err != nil {
+			t.Fatal("did not expect `ParseFile` to return error: " + err.Error())
+		}
+		return file
+	}
+	files := []*ast.File{parse("models.go", modelsSource)}
+	t.Run("generates builders of exported struct types", func(t *testing.T) {
+		source, err := generate("models", files, nil)
+		if err != nil {
+			t.Error("did not expect `generate` to return error: " + err.Error())
+			return
+		}
+		generated := string(source)
+		for _, expected := range []string{
+			"// Code generated by fragmentgen. DO NOT EDIT.",
+			"func ProfileFragment() ProfileFragmentBuilder {\n\treturn ProfileFragmentBuilder{f: fragment.NewEmptyStruct(Profile{})}\n}",
+			"func (b UserFragmentBuilder) Email() UserFragmentBuilder {\n\treturn UserFragmentBuilder{f: b.f.AddByName(\"Email\")}\n}",
+			"func (b UserFragmentBuilder) Profile(fragments ...ProfileFragmentBuilder) UserFragmentBuilder {",
+			"func (b UserFragmentBuilder) Friends(fragments ...UserFragmentBuilder) UserFragmentBuilder {",
+			"func (b UserFragmentBuilder) StructField() UserFragmentBuilder {",
+			"\t_ = v.Struct\n",
+		} {
+			if !strings.Contains(generated, expected) {
+				t.Error("expected generated source to contain " + expected)
+			}
+		}
+		if strings.Contains(generated, "secret") || strings.Contains(generated, "internalFragment") {
+			t.Error("did not expect builders of unexported fields or types")
+		}
+		if strings.Contains(generated, "Password") {
+			t.Error("did not expect builders of fields excluded from JSON")
+		}
+		if strings.Contains(generated, "Token") {
+			t.Error("did not expect builders of fields hidden from fragments")
+		}
+	})
+	t.Run("generates builders that type-check", func(t *testing.T) {
+		source, err := generate("models", files, nil)
+		if err != nil {
+			t.Error("did not expect `generate` to return error: " + err.Error())
+			return
+		}
+		config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+		pkg, err := config.Check("models", fset, append(files, parse("fragment_gen.go", string(source))), nil)
+		if err != nil {
+			t.Error("did not expect generated source to have type errors: " + err.Error())
+			return
+		}
+		builderType := pkg.Scope().Lookup("UserFragmentBuilder").Type()
+		methodResult := func(methodName string) string {
+			method, _, _ := types.LookupFieldOrMethod(builderType, false, pkg, methodName)
+			if method == nil {
+				return ""
+			}
+			return method.Type().(*types.Signature).Results().At(0).Type().String()
+		}
+		if result := methodResult("Struct"); result != "github.com/ludvigalden/go-fragment.Struct" {
+			t.Error("expected `Struct` to return `fragment.Struct`, but returns " + result)
+		}
+		for _, methodName := range []string{"Name", "Email", "Profile", "Friends", "StructField"} {
+			if result := methodResult(methodName); result != "models.UserFragmentBuilder" {
+				t.Error("expected `" + methodName + "` to return `UserFragmentBuilder`, but returns " + result)
+			}
+		}
+	})
+	t.Run("generates builders of fragments", func(t *testing.T) {
+		root, err := filepath.Abs(filepath.Join("..", ".."))
+		if err != nil {
+			t.Fatal(err)
+		}
+		goSum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mainFiles := []*ast.File{parse("models.go", strings.Replace(modelsSource, "package models", "package main", 1))}
+		source, err := generate("main", mainFiles, nil)
+		if err != nil {
+			t.Error("did not expect `generate` to return error: " + err.Error())
+			return
+		}
+		dir := t.TempDir()
+		for fileName, content := range map[string]string{
+			"go.mod":          "module models\n\ngo 1.16\n\nrequire github.com/ludvigalden/go-fragment v0.0.0\n\nreplace github.com/ludvigalden/go-fragment => " + root + "\n",
+			"go.sum":          string(goSum),
+			"models.go":       strings.Replace(modelsSource, "package models", "package main", 1),
+			"fragment_gen.go": string(source),
+			"main.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(UserFragment().Name().Profile(ProfileFragment().Bio()).Friends(UserFragment().Email(), UserFragment().Name()).Struct().Expr())
+	fmt.Println(UserFragment().Profile().StructField().Struct().Expr())
+}
+`,
+		} {
+			if err := ioutil.WriteFile(filepath.Join(dir, fileName), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cmd := exec.Command("go", "run", ".")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Error("did not expect generated builders to fail: " + err.Error() + "\n" + string(output))
+			return
+		}
+		if expected := "{ Name, Profile { Bio }, Friends { Name, Email } }\n{ Profile, Struct }\n"; string(output) != expected {
+			t.Error("expected generated builders to build " + expected + ", but built " + string(output))
+		}
+	})
+	t.Run("generates builders of specified types", func(t *testing.T) {
+		source, err := generate("models", files, []string{"User", "internal"})
+		if err != nil {
+			t.Error("did not expect `generate` to return error: " + err.Error())
+			return
+		}
+		if generated := string(source); strings.Contains(generated, "ProfileFragmentBuilder") || !strings.Contains(generated, "func internalFragment()") {
+			t.Error("expected builders of specified types only")
+		}
+		if _, err := generate("models", files, []string{"Missing"}); err == nil {
+			t.Error("expected `generate` to return error for missing type")
+		}
+	})
+	t.Run("ignores generated files", func(t *testing.T) {
+		generatedFiles := []*ast.File{parse("models_gen.go", "// Code generated by fragmentgen. DO NOT EDIT.\n\npackage models\n\ntype UserFragmentBuilder struct{}\n")}
+		if _, err := generate("models", generatedFiles, nil); err == nil {
+			t.Error("expected `generate` to return error for package without struct types")
+		}
+	})
+}
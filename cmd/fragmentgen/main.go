@@ -0,0 +1,49 @@
+// Command fragmentgen generates typed builders of fragments for the structs of a package, so that the names of the fields of fragments
+// are checked at compile time rather than when the fragments are built, e.g. `UserFragment().Name().Profile(ProfileFragment().Bio())`
+// instead of `fragment.NewEmptyStruct(User{}).AddByName("Name").SetByName("Profile", ...)`. It is meant to be run using `go generate`:
+//
+//	//go:generate go run github.com/ludvigalden/go-fragment/cmd/fragmentgen -type User,Profile
+//
+// Builders are generated for the specified types, or for all exported struct types of the package if no types are specified, and are written
+// to `fragment_gen.go` in the directory of the package unless another output is specified.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct types to generate builders for; defaults to all exported struct types")
+	output := flag.String("output", "", "output file; defaults to fragment_gen.go in the directory of the package")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: fragmentgen [-type T1,T2] [-output file] [directory]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+	source, err := generateDir(dir, types)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fragmentgen: "+err.Error())
+		os.Exit(1)
+	}
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = filepath.Join(dir, "fragment_gen.go")
+	}
+	if err := ioutil.WriteFile(outputPath, source, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "fragmentgen: "+err.Error())
+		os.Exit(1)
+	}
+}
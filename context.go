@@ -0,0 +1,39 @@
+package fragment
+
+import (
+	"context"
+	"errors"
+)
+
+// WithFragment returns a copy of the context carrying the fragment, which can be retrieved using `FromContext`.
+func WithFragment(ctx context.Context, fragment Struct) context.Context {
+	return context.WithValue(ctx, contextKey{}, fragment)
+}
+
+// FromContext returns the fragment carried by the context, and false if the context does not carry a fragment.
+func FromContext(ctx context.Context) (Struct, bool) {
+	fragment, ok := ctx.Value(contextKey{}).(Struct)
+	return fragment, ok
+}
+
+// FieldFromContext returns the fragment at a path relative to the fragment carried by the context, and a child context carrying it.
+// The path is parsed using `ParseStructPath` against the type of the carried fragment, e.g. "profile.address" or a list of field names.
+// If a field of the path is not included in the carried fragment, an empty fragment is returned, see `StructPath.FragmentAt`.
+// If the context does not carry a fragment, the context is returned together with an invalid fragment.
+func FieldFromContext(ctx context.Context, path ...interface{}) (context.Context, Struct, error) {
+	fragment, ok := FromContext(ctx)
+	if !ok {
+		return ctx, Struct{}, nil
+	}
+	if !fragment.IsValid() {
+		return ctx, Struct{}, errors.New("cannot derive field fragment from invalid fragment")
+	}
+	structPath, err := ParseStructPath(fragment.TypeMeta(), path...)
+	if err != nil {
+		return ctx, Struct{}, err
+	}
+	fieldFragment := structPath.FragmentAt(fragment)
+	return WithFragment(ctx, fieldFragment), fieldFragment, nil
+}
+
+type contextKey struct{}
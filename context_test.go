@@ -0,0 +1,57 @@
+package fragment
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	type Address struct {
+		City    string `json:"city"`
+		Country string `json:"country"`
+	}
+	type Profile struct {
+		Bio     string   `json:"bio"`
+		Address *Address `json:"address"`
+	}
+	type User struct {
+		Name    string   `json:"name"`
+		Profile *Profile `json:"profile"`
+		Friends []User   `json:"friends"`
+	}
+	fragment, _ := ParseStruct(User{}, "name, profile { address { city } }")
+	ctx := WithFragment(context.Background(), fragment)
+	t.Run("carries fragment", func(t *testing.T) {
+		carried, ok := FromContext(ctx)
+		if !ok || carried.Expr() != fragment.Expr() {
+			t.Error("expected context to carry fragment")
+		}
+		if _, ok := FromContext(context.Background()); ok {
+			t.Error("did not expect context to carry fragment")
+		}
+	})
+	t.Run("derives field fragments", func(t *testing.T) {
+		profileCtx, profileFragment, err := FieldFromContext(ctx, "profile")
+		if err != nil {
+			t.Error("did not expect `FieldFromContext` to return error: " + err.Error())
+			return
+		}
+		if expr := profileFragment.Expr(); expr != "{ Address { City } }" {
+			t.Error("unexpected field fragment " + expr)
+		}
+		if carried, _ := FromContext(profileCtx); carried.Expr() != profileFragment.Expr() {
+			t.Error("expected child context to carry field fragment")
+		}
+		_, addressFragment, err := FieldFromContext(profileCtx, "address")
+		if err != nil || addressFragment.Expr() != "{ City }" {
+			t.Error("expected field fragment to be derived from child context")
+		}
+		_, friendsFragment, err := FieldFromContext(ctx, "friends")
+		if err != nil || !friendsFragment.IsEmpty() {
+			t.Error("expected empty fragment for field not included in fragment")
+		}
+		if _, _, err := FieldFromContext(ctx, "age"); err == nil {
+			t.Error("expected `FieldFromContext` to return error for non-existing field")
+		}
+	})
+}
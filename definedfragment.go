@@ -0,0 +1,51 @@
+package fragment
+
+import (
+	"reflect"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// DefinedFragment returns the fragment of the fields of a struct value, or a pointer to a struct value, that are defined as determined by the policy.
+// Fields of structs and pointers to structs are walked recursively, so that a defined nested struct yields a nested fragment of its defined fields.
+// This is useful for update requests with pointer fields, e.g. `{ Name *string; Address *Address }`, to get the fragment of the provided fields.
+// If the value is not a struct or a pointer to a struct, an invalid fragment is returned. A policy can be passed to determine which values
+// are undefined, otherwise `DefaultPolicy` is used.
+func DefinedFragment(value interface{}, policy ...Policy) Struct {
+	reflectValue := reflect.ValueOf(value)
+	if !reflectValue.IsValid() {
+		return Struct{}
+	}
+	structTypeMeta, ok := typemeta.NonPtr(typemeta.Get(reflectValue.Type())).(*typemeta.Struct)
+	if !ok {
+		return Struct{}
+	}
+	for reflectValue.Kind() == reflect.Ptr {
+		if reflectValue.IsNil() {
+			return NewEmptyStruct(structTypeMeta)
+		}
+		reflectValue = reflectValue.Elem()
+	}
+	return definedFragment(structTypeMeta, reflectValue, policyOf(policy))
+}
+
+func definedFragment(structTypeMeta *typemeta.Struct, value reflect.Value, policy Policy) Struct {
+	fragment := NewEmptyStruct(structTypeMeta)
+	structTypeMeta.IterateFields(func(structField typemeta.StructField) {
+		fieldValue := value.Field(structField.Index)
+		if policy.IsValueUndefined(fieldValue) {
+			return
+		}
+		if fieldStructTypeMeta, ok := typemeta.NonPtr(structField.TypeMeta).(*typemeta.Struct); ok && !isNullableType(fieldStructTypeMeta.Type()) {
+			for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				fragment = fragment.Set(structField.Index, definedFragment(fieldStructTypeMeta, fieldValue, policy))
+				return
+			}
+		}
+		fragment = fragment.Add(structField.Index)
+	})
+	return fragment
+}
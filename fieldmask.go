@@ -0,0 +1,246 @@
+package fragment
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// ParseFieldMask parses the paths of a `google.protobuf.FieldMask`, e.g. `[]string{"user.display_name", "user.email"}`, into an unstructured fragment,
+// e.g. `{ user { display_name, email } }`. Following AIP-161, the sole path `*` selects all fields and results in an undefined fragment,
+// `*` segments select every element of repeated fields and maps and are skipped, and segments may be quoted with backticks, e.g. "labels.`my-key`".
+// Since the fragment is not typed, map keys are parsed as field names.
+func ParseFieldMask(paths []string) (Unstructured, error) {
+	fragment := NewEmptyUnstructured()
+	for _, path := range paths {
+		if strings.TrimSpace(path) == "*" {
+			return NewUnstructured(), nil
+		}
+		segments, err := splitFieldMaskPath(path)
+		if err != nil {
+			return fragment, err
+		}
+		fieldNames := []string{}
+		for _, segment := range segments {
+			if segment != "*" {
+				fieldNames = append(fieldNames, segment)
+			}
+		}
+		if len(fieldNames) == 0 {
+			return fragment, errors.New("invalid field mask path \"" + path + "\"")
+		}
+		fragment = fragment.Assign(NewUnstructuedPath(fieldNames).ToFragment())
+	}
+	return fragment, nil
+}
+
+// ParseStructFieldMask parses the paths of a `google.protobuf.FieldMask` into a fragment for the specified struct type, see `ParseFieldMaskPath`.
+// The sole path `*` selects all fields and results in a fragment with every field. Since fragments do not select map keys, map key segments
+// select every element of the map.
+func ParseStructFieldMask(t interface{}, paths []string) (Struct, error) {
+	fragment, err := ParseStruct(t)
+	if err != nil {
+		return fragment, err
+	} else if !fragment.IsValid() {
+		return fragment, errors.New("cannot parse field mask for non-struct type " + typemeta.Get(t).String())
+	}
+	fragment = fragment.Clear()
+	for _, path := range paths {
+		if strings.TrimSpace(path) == "*" {
+			return NewCompleteStruct(fragment.TypeMeta()), nil
+		}
+		structPath, err := ParseFieldMaskPath(t, path)
+		if err != nil {
+			return fragment, err
+		}
+		fragment = fragment.AssignStruct(structPath.ToStructFragment())
+	}
+	return fragment, nil
+}
+
+// ParseFieldMaskPath parses a path of a `google.protobuf.FieldMask`, e.g. `user.display_name`, into a path for the specified struct type.
+// Segments are matched against the `name` of the `protobuf` tag of struct fields, and otherwise against the snake_case JSON names and struct field names.
+// Following AIP-161, segments following map fields are parsed as key selectors, `*` segments are parsed as wildcard selectors,
+// and segments may be quoted with backticks, e.g. "labels.`my-key`".
+func ParseFieldMaskPath(t interface{}, path string) (StructPath, error) {
+	segments, err := splitFieldMaskPath(path)
+	if err != nil {
+		return StructPath{}, err
+	}
+	builder, err := newStructPathBuilder(t)
+	if err != nil {
+		return StructPath{}, err
+	}
+	for _, segment := range segments {
+		switch typemeta.NonPtr(builder.currentValueType).(type) {
+		case *typemeta.Map:
+			if segment == "*" {
+				err = builder.addSelectors(WildcardSelector())
+			} else {
+				err = builder.addSelectors(KeySelector(segment))
+			}
+		case *typemeta.Slice, *typemeta.Array:
+			if segment == "*" {
+				err = builder.addSelectors(WildcardSelector())
+			} else {
+				err = builder.addFieldByFieldMaskName(segment)
+			}
+		default:
+			err = builder.addFieldByFieldMaskName(segment)
+		}
+		if err != nil {
+			return builder.path, errors.New(err.Error() + " in field mask path \"" + path + "\"")
+		}
+	}
+	return builder.build(), nil
+}
+
+// FieldMask returns the paths of a `google.protobuf.FieldMask` selecting the fields of the fragment, e.g. `[]string{"user.display_name"}`,
+// using the `name` of the `protobuf` tag of struct fields, and otherwise the snake_case JSON names. If the fragment is undefined, `[]string{"*"}` is returned.
+func (f Struct) FieldMask() []string {
+	if f.IsUndefined() {
+		return []string{"*"}
+	}
+	paths := []string{}
+	f.IterateFields(func(field StructField) {
+		fieldMaskName := quoteFieldMaskSegment(fieldMaskName(field.StructField))
+		if field.Fragment.IsUndefinedOrEmpty() {
+			paths = append(paths, fieldMaskName)
+			return
+		}
+		for _, fieldPath := range field.Fragment.FieldMask() {
+			paths = append(paths, fieldMaskName+"."+fieldPath)
+		}
+	})
+	return paths
+}
+
+// FieldMask returns the sorted paths of a `google.protobuf.FieldMask` selecting the fields of the fragment, e.g. `[]string{"user.display_name"}`.
+// If the fragment is undefined, `[]string{"*"}` is returned.
+func (f Unstructured) FieldMask() []string {
+	if f.IsUndefined() {
+		return []string{"*"}
+	}
+	paths := []string{}
+	f.IterateFields(func(fieldName string, fieldFragment Fragment) {
+		quotedFieldName := quoteFieldMaskSegment(fieldName)
+		fieldUnstructured, ok := fieldFragment.(Unstructured)
+		if !ok && fieldFragment != nil {
+			fieldUnstructured, _ = ParseUnstructured(fieldFragment.JSONExpr())
+		}
+		if fieldUnstructured.IsUndefinedOrEmpty() {
+			paths = append(paths, quotedFieldName)
+			return
+		}
+		for _, fieldPath := range fieldUnstructured.FieldMask() {
+			paths = append(paths, quotedFieldName+"."+fieldPath)
+		}
+	})
+	sort.Strings(paths)
+	return paths
+}
+
+func (b *structPathBuilder) addFieldByFieldMaskName(name string) error {
+	if name == "*" {
+		return errors.New("unexpected wildcard for non-repeated and non-map type " + b.currentValueType.String())
+	} else if b.currentTypeMeta == nil {
+		return errors.New("cannot select field \"" + name + "\" of non-struct type " + b.currentValueType.String())
+	}
+	structField := b.currentTypeMeta.FindField(func(structField typemeta.StructField) bool {
+		return fieldMaskName(structField) == name
+	})
+	if structField == nil {
+		structField = b.currentTypeMeta.FindField(func(structField typemeta.StructField) bool {
+			return toSnakeCase(structField.Name) == name || structField.JSONName == name || structField.Name == name
+		})
+	}
+	if structField == nil {
+		return errors.New("field with name \"" + name + "\" does not exist in " + b.currentTypeMeta.String())
+	}
+	b.addField(*structField)
+	return nil
+}
+
+// fieldMaskName returns the name of a struct field in a field mask, i.e. the `name` of the `protobuf` tag if defined, and otherwise the snake_case JSON name or struct field name
+func fieldMaskName(structField typemeta.StructField) string {
+	if protobufTag := structField.Tag("protobuf"); protobufTag != nil {
+		for _, option := range append([]string{protobufTag.Name}, protobufTag.Options...) {
+			if strings.HasPrefix(option, "name=") {
+				return strings.TrimPrefix(option, "name=")
+			}
+		}
+	}
+	if structField.JSONName != "" {
+		return toSnakeCase(structField.JSONName)
+	}
+	return toSnakeCase(structField.Name)
+}
+
+// splitFieldMaskPath splits a field mask path into segments, where segments quoted with backticks may contain dots and backticks escaped by doubling them
+func splitFieldMaskPath(path string) ([]string, error) {
+	segments := []string{}
+	chars := []rune(strings.TrimSpace(path))
+	current := ""
+	quoted := false
+	wasQuoted := false
+	for i := 0; i < len(chars); i++ {
+		char := chars[i]
+		switch {
+		case char == '`' && quoted && i+1 < len(chars) && chars[i+1] == '`':
+			current += "`"
+			i++
+		case char == '`':
+			if !quoted && current != "" {
+				return nil, errors.New("unexpected backtick in field mask path \"" + path + "\"")
+			}
+			quoted = !quoted
+			wasQuoted = true
+		case char == '.' && !quoted:
+			if current == "" && !wasQuoted {
+				return nil, errors.New("empty segment in field mask path \"" + path + "\"")
+			}
+			segments = append(segments, current)
+			current = ""
+			wasQuoted = false
+		default:
+			current += string(char)
+		}
+	}
+	if quoted {
+		return nil, errors.New("missing closing backtick in field mask path \"" + path + "\"")
+	} else if current == "" && !wasQuoted {
+		return nil, errors.New("empty segment in field mask path \"" + path + "\"")
+	}
+	return append(segments, current), nil
+}
+
+// quoteFieldMaskSegment quotes a segment with backticks unless it only contains alphanumerics and underscores, or is a wildcard
+func quoteFieldMaskSegment(segment string) string {
+	if segment == "*" || fieldMaskIdentifierRegexp.MatchString(segment) {
+		return segment
+	}
+	return "`" + strings.ReplaceAll(segment, "`", "``") + "`"
+}
+
+// toSnakeCase converts a name such as "displayName", "DisplayName", or "HTTPServer" to snake_case, e.g. "display_name" and "http_server"
+func toSnakeCase(name string) string {
+	chars := []rune(name)
+	snake := []rune{}
+	for i, char := range chars {
+		if unicode.IsUpper(char) {
+			if i > 0 && chars[i-1] != '_' && (unicode.IsLower(chars[i-1]) || unicode.IsDigit(chars[i-1]) || (i+1 < len(chars) && unicode.IsLower(chars[i+1]))) {
+				snake = append(snake, '_')
+			}
+			snake = append(snake, unicode.ToLower(char))
+		} else {
+			snake = append(snake, char)
+		}
+	}
+	return string(snake)
+}
+
+var fieldMaskIdentifierRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
@@ -0,0 +1,96 @@
+package fragment
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFieldMask(t *testing.T) {
+	type Profile struct {
+		DisplayName string `json:"displayName"`
+		Email       string `json:"email"`
+	}
+	type Book struct {
+		Title  string `protobuf:"bytes,1,opt,name=book_title,json=bookTitle,proto3" json:"book_title,omitempty"`
+		Author string `json:"author"`
+	}
+	type User struct {
+		ID      string            `json:"id"`
+		Profile *Profile          `json:"profile"`
+		Books   []Book            `json:"books"`
+		Labels  map[string]string `json:"labels"`
+	}
+	t.Run("parses struct field masks", func(t *testing.T) {
+		fragment, err := ParseStructFieldMask(User{}, []string{"id", "profile.display_name", "books.*.book_title", "labels.`my-key`"})
+		if err != nil {
+			t.Error("did not expect `ParseStructFieldMask` to return error: " + err.Error())
+			return
+		}
+		if expr := fragment.Expr(); expr != "{ ID, Profile { DisplayName }, Books { Title }, Labels }" {
+			t.Error("unexpected fragment " + expr)
+		}
+		if mask := strings.Join(fragment.FieldMask(), ","); mask != "id,profile.display_name,books.book_title,labels" {
+			t.Error("unexpected field mask " + mask)
+		}
+	})
+	t.Run("parses field mask paths with selectors", func(t *testing.T) {
+		path, err := ParseFieldMaskPath(User{}, "labels.`my.key`")
+		if err != nil {
+			t.Error("did not expect `ParseFieldMaskPath` to return error: " + err.Error())
+			return
+		}
+		if expr := path.JSONExpr(); expr != `labels["my.key"]` {
+			t.Error("unexpected path " + expr)
+		}
+		path, err = ParseFieldMaskPath(User{}, "books.*.author")
+		if err != nil {
+			t.Error("did not expect `ParseFieldMaskPath` to return error: " + err.Error())
+			return
+		}
+		if expr := path.JSONExpr(); expr != "books[*].author" {
+			t.Error("unexpected path " + expr)
+		}
+	})
+	t.Run("validates field mask paths", func(t *testing.T) {
+		for _, path := range []string{"name", "profile.*", "profile..email", "labels.`en", "id.value"} {
+			if _, err := ParseStructFieldMask(User{}, []string{path}); err == nil {
+				t.Error("expected `ParseStructFieldMask` to return error for path " + path)
+			}
+		}
+	})
+	t.Run("parses wildcard field masks", func(t *testing.T) {
+		fragment, err := ParseStructFieldMask(User{}, []string{"*"})
+		if err != nil {
+			t.Error("did not expect `ParseStructFieldMask` to return error: " + err.Error())
+			return
+		}
+		if fragment.FieldsLen() != 4 {
+			t.Error("expected wildcard field mask to select every field")
+		}
+		unstructured, err := ParseFieldMask([]string{"*"})
+		if err != nil {
+			t.Error("did not expect `ParseFieldMask` to return error: " + err.Error())
+			return
+		}
+		if !unstructured.IsUndefined() || strings.Join(unstructured.FieldMask(), ",") != "*" {
+			t.Error("expected wildcard field mask to result in an undefined fragment")
+		}
+	})
+	t.Run("converts unstructured fragments", func(t *testing.T) {
+		fragment, err := ParseFieldMask([]string{"user.display_name", "user.email", "labels.`my-key`", "books.*.title"})
+		if err != nil {
+			t.Error("did not expect `ParseFieldMask` to return error: " + err.Error())
+			return
+		}
+		if mask := strings.Join(fragment.FieldMask(), ","); mask != "books.title,labels.`my-key`,user.display_name,user.email" {
+			t.Error("unexpected field mask " + mask)
+		}
+	})
+	t.Run("converts names to snake case", func(t *testing.T) {
+		for name, expected := range map[string]string{"displayName": "display_name", "UserID": "user_id", "HTTPServer": "http_server", "id": "id", "already_snake": "already_snake"} {
+			if snake := toSnakeCase(name); snake != expected {
+				t.Error("expected " + name + " to be converted to " + expected + ", got " + snake)
+			}
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package fragmentgraphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// coerceValue coerces a value, either decoded from JSON variables or a literal value of the document, to a GraphQL input type.
+// Int values are coerced to int, Float values to float64, String and ID values to string, and Boolean values to bool. Lists are coerced
+// to `[]interface{}`, where a non-list value is coerced to a list of one element. Values of other types, i.e. enums and input objects, are not coerced.
+func coerceValue(value interface{}, t *typeRef) (interface{}, error) {
+	if value == nil {
+		if t.nonNull {
+			return nil, errors.New("Expected non-nullable type \"" + t.String() + "\" not to be null.")
+		}
+		return nil, nil
+	}
+	if t.elem != nil {
+		list, ok := value.([]interface{})
+		if !ok {
+			elemValue, err := coerceValue(value, t.elem)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{elemValue}, nil
+		}
+		coerced := make([]interface{}, len(list))
+		for i, elem := range list {
+			elemValue, err := coerceValue(elem, t.elem)
+			if err != nil {
+				return nil, err
+			}
+			coerced[i] = elemValue
+		}
+		return coerced, nil
+	}
+	switch t.name {
+	case "Int":
+		if i, ok := integerOf(value); ok && i >= math.MinInt32 && i <= math.MaxInt32 {
+			return int(i), nil
+		}
+		return nil, errors.New("Int cannot represent non 32-bit signed integer value: " + valueString(value))
+	case "Float":
+		if f, ok := floatOf(value); ok {
+			return f, nil
+		}
+		return nil, errors.New("Float cannot represent non numeric value: " + valueString(value))
+	case "String":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return nil, errors.New("String cannot represent a non string value: " + valueString(value))
+	case "Boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, errors.New("Boolean cannot represent a non boolean value: " + valueString(value))
+	case "ID":
+		if s, ok := value.(string); ok {
+			return s, nil
+		} else if i, ok := integerOf(value); ok {
+			return strconv.FormatInt(i, 10), nil
+		}
+		return nil, errors.New("ID cannot represent value: " + valueString(value))
+	}
+	return value, nil
+}
+
+// integerOf returns the integer of a value if it is an integer, or a float or JSON number without a fractional part
+func integerOf(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case int:
+		return int64(value), true
+	case int32:
+		return int64(value), true
+	case int64:
+		return value, true
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			return int64(value), true
+		}
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func floatOf(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	}
+	if i, ok := integerOf(value); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// valueString returns the JSON representation of a value for error messages
+func valueString(value interface{}) string {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(bytes)
+}
+
+func parseIntLiteral(literal string) (int64, bool) {
+	i, err := strconv.ParseInt(literal, 10, 64)
+	return i, err == nil
+}
+
+func parseFloatLiteral(literal string) (float64, bool) {
+	f, err := strconv.ParseFloat(literal, 64)
+	return f, err == nil
+}
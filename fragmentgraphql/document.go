@@ -0,0 +1,502 @@
+package fragmentgraphql
+
+// document is a parsed GraphQL executable document
+type document struct {
+	operations []*operationDefinition
+	fragments  map[string]*fragmentDefinition
+}
+
+type operationDefinition struct {
+	operationType OperationType
+	name          string
+	variables     []variableDefinition
+	directives    []directive
+	selectionSet  []selection
+	location      Location
+}
+
+type fragmentDefinition struct {
+	name          string
+	typeCondition string
+	directives    []directive
+	selectionSet  []selection
+	location      Location
+}
+
+type variableDefinition struct {
+	name         string
+	typeRef      *typeRef
+	defaultValue interface{}
+	location     Location
+}
+
+// typeRef is a reference to a GraphQL type, e.g. `[ID!]!`, where list types have an element type
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type selectionKind int
+
+const (
+	fieldSelection selectionKind = iota
+	fragmentSpreadSelection
+	inlineFragmentSelection
+)
+
+// selection is a field, a fragment spread, or an inline fragment of a selection set
+type selection struct {
+	kind selectionKind
+	// alias is the alias of a field, if any
+	alias string
+	// name is the name of a field or of a spread fragment
+	name          string
+	arguments     []argument
+	directives    []directive
+	typeCondition string
+	selectionSet  []selection
+	location      Location
+}
+
+// responseKey returns the key of a field in the response, i.e. its alias or name
+func (s selection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+type argument struct {
+	name     string
+	value    interface{}
+	location Location
+}
+
+type directive struct {
+	name      string
+	arguments []argument
+	location  Location
+}
+
+// Values of a document are represented as `variableValue`, `intValue`, `floatValue`, `enumValue`, string, bool, nil, `[]interface{}`, or `objectValue`
+type (
+	variableValue string
+	intValue      string
+	floatValue    string
+	enumValue     string
+	objectValue   []argument
+)
+
+// parser parses a GraphQL executable document by recursive descent
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+// parseDocument parses a GraphQL executable document, i.e. operations and fragment definitions
+func parseDocument(source string) (*document, error) {
+	p := &parser{lexer: newLexer(source)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &document{fragments: map[string]*fragmentDefinition{}}
+	if p.token.kind == tokenEOF {
+		return nil, p.unexpected()
+	}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			location := p.token.location
+			selectionSet, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operationDefinition{operationType: Query, selectionSet: selectionSet, location: location})
+		case p.peek(tokenName, string(Query)), p.peek(tokenName, string(Mutation)), p.peek(tokenName, string(Subscription)):
+			operation, err := p.parseOperationDefinition()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, operation)
+		case p.peek(tokenName, "fragment"):
+			fragment, err := p.parseFragmentDefinition()
+			if err != nil {
+				return nil, err
+			} else if _, ok := doc.fragments[fragment.name]; ok {
+				return nil, Error{Message: "There can be only one fragment named \"" + fragment.name + "\".", Locations: []Location{doc.fragments[fragment.name].location, fragment.location}}
+			}
+			doc.fragments[fragment.name] = fragment
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	token, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = token
+	return nil
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.token.kind == kind && p.token.value == value
+}
+
+func (p *parser) unexpected() error {
+	return p.lexer.syntaxError(p.token.location, "Unexpected "+p.token.String()+".")
+}
+
+// skip advances past the specified token and returns true if it is the current token
+func (p *parser) skip(kind tokenKind, value string) (bool, error) {
+	if !p.peek(kind, value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.peek(kind, value) {
+		return p.lexer.syntaxError(p.token.location, "Expected \""+value+"\", found "+p.token.String()+".")
+	}
+	return p.advance()
+}
+
+func (p *parser) parseName() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.lexer.syntaxError(p.token.location, "Expected Name, found "+p.token.String()+".")
+	}
+	name := p.token.value
+	return name, p.advance()
+}
+
+func (p *parser) parseOperationDefinition() (*operationDefinition, error) {
+	operation := &operationDefinition{operationType: OperationType(p.token.value), location: p.token.location}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.token.kind == tokenName {
+		operation.name = p.token.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.skip(tokenPunctuator, "("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(tokenPunctuator, ")") {
+			variable, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			operation.variables = append(operation.variables, variable)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	directives, err := p.parseDirectives(false)
+	if err != nil {
+		return nil, err
+	}
+	operation.directives = directives
+	if operation.selectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+func (p *parser) parseVariableDefinition() (variableDefinition, error) {
+	variable := variableDefinition{location: p.token.location}
+	if err := p.expect(tokenPunctuator, "$"); err != nil {
+		return variable, err
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return variable, err
+	}
+	variable.name = name
+	if err := p.expect(tokenPunctuator, ":"); err != nil {
+		return variable, err
+	}
+	if variable.typeRef, err = p.parseTypeRef(); err != nil {
+		return variable, err
+	}
+	if ok, err := p.skip(tokenPunctuator, "="); err != nil {
+		return variable, err
+	} else if ok {
+		if variable.defaultValue, err = p.parseValue(true); err != nil {
+			return variable, err
+		}
+	}
+	if _, err := p.parseDirectives(true); err != nil {
+		return variable, err
+	}
+	return variable, nil
+}
+
+func (p *parser) parseTypeRef() (*typeRef, error) {
+	t := &typeRef{}
+	if ok, err := p.skip(tokenPunctuator, "["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.parseTypeRef()
+		if err != nil {
+			return nil, err
+		}
+		t.elem = elem
+		if err := p.expect(tokenPunctuator, "]"); err != nil {
+			return nil, err
+		}
+	} else {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		t.name = name
+	}
+	ok, err := p.skip(tokenPunctuator, "!")
+	t.nonNull = ok
+	return t, err
+}
+
+func (p *parser) parseFragmentDefinition() (*fragmentDefinition, error) {
+	fragment := &fragmentDefinition{location: p.token.location}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenName, "on") {
+		return nil, p.unexpected()
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	fragment.name = name
+	if err := p.expect(tokenName, "on"); err != nil {
+		return nil, err
+	}
+	if fragment.typeCondition, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if fragment.directives, err = p.parseDirectives(true); err != nil {
+		return nil, err
+	}
+	if fragment.selectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.expect(tokenPunctuator, "{"); err != nil {
+		return nil, err
+	}
+	selections := []selection{}
+	for {
+		if ok, err := p.skip(tokenPunctuator, "}"); err != nil {
+			return nil, err
+		} else if ok {
+			if len(selections) == 0 {
+				return nil, p.lexer.syntaxError(p.token.location, "Expected Name, found \"}\".")
+			}
+			return selections, nil
+		}
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	s := selection{location: p.token.location}
+	var err error
+	if ok, err := p.skip(tokenPunctuator, "..."); err != nil {
+		return s, err
+	} else if ok {
+		if p.token.kind == tokenName && p.token.value != "on" {
+			s.kind = fragmentSpreadSelection
+			s.name = p.token.value
+			if err := p.advance(); err != nil {
+				return s, err
+			}
+			s.directives, err = p.parseDirectives(false)
+			return s, err
+		}
+		s.kind = inlineFragmentSelection
+		if ok, err := p.skip(tokenName, "on"); err != nil {
+			return s, err
+		} else if ok {
+			if s.typeCondition, err = p.parseName(); err != nil {
+				return s, err
+			}
+		}
+		if s.directives, err = p.parseDirectives(false); err != nil {
+			return s, err
+		}
+		s.selectionSet, err = p.parseSelectionSet()
+		return s, err
+	}
+	s.kind = fieldSelection
+	if s.name, err = p.parseName(); err != nil {
+		return s, err
+	}
+	if ok, err := p.skip(tokenPunctuator, ":"); err != nil {
+		return s, err
+	} else if ok {
+		s.alias = s.name
+		if s.name, err = p.parseName(); err != nil {
+			return s, err
+		}
+	}
+	if s.arguments, err = p.parseArguments(false); err != nil {
+		return s, err
+	}
+	if s.directives, err = p.parseDirectives(false); err != nil {
+		return s, err
+	}
+	if p.peek(tokenPunctuator, "{") {
+		if s.selectionSet, err = p.parseSelectionSet(); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) parseArguments(constant bool) ([]argument, error) {
+	if ok, err := p.skip(tokenPunctuator, "("); err != nil || !ok {
+		return nil, err
+	}
+	arguments := []argument{}
+	for {
+		if ok, err := p.skip(tokenPunctuator, ")"); err != nil {
+			return nil, err
+		} else if ok {
+			if len(arguments) == 0 {
+				return nil, p.lexer.syntaxError(p.token.location, "Expected Name, found \")\".")
+			}
+			return arguments, nil
+		}
+		argument, err := p.parseArgument(constant)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+	}
+}
+
+func (p *parser) parseArgument(constant bool) (argument, error) {
+	a := argument{location: p.token.location}
+	name, err := p.parseName()
+	if err != nil {
+		return a, err
+	}
+	a.name = name
+	if err := p.expect(tokenPunctuator, ":"); err != nil {
+		return a, err
+	}
+	a.value, err = p.parseValue(constant)
+	return a, err
+}
+
+func (p *parser) parseDirectives(constant bool) ([]directive, error) {
+	directives := []directive{}
+	for p.peek(tokenPunctuator, "@") {
+		d := directive{location: p.token.location}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		d.name = name
+		if d.arguments, err = p.parseArguments(constant); err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// parseValue parses a value, where variables are not allowed if the value is constant, e.g. the default value of a variable
+func (p *parser) parseValue(constant bool) (interface{}, error) {
+	current := p.token
+	switch current.kind {
+	case tokenPunctuator:
+		switch current.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected()
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.parseName()
+			return variableValue(name), err
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			list := []interface{}{}
+			for !p.peek(tokenPunctuator, "]") {
+				value, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			return list, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			object := objectValue{}
+			for !p.peek(tokenPunctuator, "}") {
+				field, err := p.parseArgument(constant)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, field)
+			}
+			return object, p.advance()
+		}
+	case tokenInt:
+		return intValue(current.value), p.advance()
+	case tokenFloat:
+		return floatValue(current.value), p.advance()
+	case tokenString:
+		return current.value, p.advance()
+	case tokenName:
+		var value interface{}
+		switch current.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = enumValue(current.value)
+		}
+		return value, p.advance()
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,57 @@
+package fragmentgraphql
+
+import (
+	"strings"
+)
+
+// Location is a location in a GraphQL document, where lines and columns start at 1
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an error in the GraphQL error format, e.g. `{ "message": "...", "locations": [{ "line": 1, "column": 3 }], "path": ["user"] }`
+type Error struct {
+	// Message is a human-readable description of the error
+	Message string `json:"message"`
+	// Locations is the locations in the document that the error relates to, if any
+	Locations []Location `json:"locations,omitempty"`
+	// Path is the path of the response field that the error relates to, if any, where strings are field names and integers are list indices
+	Path []interface{} `json:"path,omitempty"`
+	// Extensions is additional information about the error, if any
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+// Errors is the errors of a GraphQL request, which is marshaled as the `errors` of a GraphQL response
+type Errors []Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Response is a GraphQL response, e.g. `{ "data": { ... } }` or `{ "errors": [{ "message": "..." }] }`
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors Errors      `json:"errors,omitempty"`
+}
+
+// errorsOf returns the errors of an error, which is returned as is if it is `Errors`
+func errorsOf(err error) Errors {
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case Errors:
+		return err
+	case Error:
+		return Errors{err}
+	}
+	return Errors{{Message: err.Error()}}
+}
@@ -0,0 +1,317 @@
+package fragmentgraphql
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "<EOF>"
+	case tokenPunctuator:
+		return "Punctuator"
+	case tokenName:
+		return "Name"
+	case tokenInt:
+		return "Int"
+	case tokenFloat:
+		return "Float"
+	case tokenString:
+		return "String"
+	}
+	return "Unknown"
+}
+
+// token is a lexical token of a GraphQL document. The value of string tokens is the unescaped string.
+type token struct {
+	kind     tokenKind
+	value    string
+	location Location
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "<EOF>"
+	case tokenString:
+		return strconv.Quote(t.value)
+	case tokenName:
+		return "Name \"" + t.value + "\""
+	case tokenPunctuator:
+		return "\"" + t.value + "\""
+	}
+	return t.kind.String() + " \"" + t.value + "\""
+}
+
+// lexer reads the tokens of a GraphQL document, ignoring whitespace, commas, and comments
+type lexer struct {
+	source string
+	offset int
+	line   int
+	// lineStart is the offset of the start of the current line, used to compute columns
+	lineStart int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{source: source, line: 1}
+}
+
+func (l *lexer) location() Location {
+	return Location{Line: l.line, Column: utf8.RuneCountInString(l.source[l.lineStart:l.offset]) + 1}
+}
+
+func (l *lexer) syntaxError(location Location, message string) error {
+	return Error{Message: "Syntax Error: " + message, Locations: []Location{location}}
+}
+
+func (l *lexer) newline() {
+	l.line++
+	l.lineStart = l.offset
+}
+
+func (l *lexer) skipIgnored() {
+	for l.offset < len(l.source) {
+		switch char := l.source[l.offset]; char {
+		case ' ', '\t', ',':
+			l.offset++
+		case '\n':
+			l.offset++
+			l.newline()
+		case '\r':
+			l.offset++
+			if l.offset < len(l.source) && l.source[l.offset] == '\n' {
+				l.offset++
+			}
+			l.newline()
+		case '#':
+			for l.offset < len(l.source) && l.source[l.offset] != '\n' && l.source[l.offset] != '\r' {
+				l.offset++
+			}
+		default:
+			if strings.HasPrefix(l.source[l.offset:], "\uFEFF") {
+				l.offset += len("\uFEFF")
+				continue
+			}
+			return
+		}
+	}
+}
+
+// next returns the next token of the document
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	location := l.location()
+	if l.offset >= len(l.source) {
+		return token{kind: tokenEOF, location: location}, nil
+	}
+	char := l.source[l.offset]
+	switch {
+	case strings.IndexByte("!$&()=:@[]{}|", char) >= 0:
+		l.offset++
+		return token{kind: tokenPunctuator, value: string(char), location: location}, nil
+	case char == '.':
+		if !strings.HasPrefix(l.source[l.offset:], "...") {
+			return token{}, l.syntaxError(location, "Unexpected \".\", did you mean \"...\"?")
+		}
+		l.offset += 3
+		return token{kind: tokenPunctuator, value: "...", location: location}, nil
+	case isNameStart(char):
+		start := l.offset
+		for l.offset < len(l.source) && isNameContinue(l.source[l.offset]) {
+			l.offset++
+		}
+		return token{kind: tokenName, value: l.source[start:l.offset], location: location}, nil
+	case char == '-' || isDigit(char):
+		return l.readNumber(location)
+	case char == '"':
+		if strings.HasPrefix(l.source[l.offset:], `"""`) {
+			return l.readBlockString(location)
+		}
+		return l.readString(location)
+	}
+	r, _ := utf8.DecodeRuneInString(l.source[l.offset:])
+	return token{}, l.syntaxError(location, "Unexpected character "+strconv.QuoteRune(r)+".")
+}
+
+func (l *lexer) readNumber(location Location) (token, error) {
+	start := l.offset
+	kind := tokenInt
+	if l.source[l.offset] == '-' {
+		l.offset++
+	}
+	if err := l.readDigits(location); err != nil {
+		return token{}, err
+	}
+	if digits := strings.TrimPrefix(l.source[start:l.offset], "-"); len(digits) > 1 && digits[0] == '0' {
+		return token{}, l.syntaxError(location, "Invalid number, unexpected digit after 0: \""+digits[1:2]+"\".")
+	}
+	if l.offset < len(l.source) && l.source[l.offset] == '.' {
+		kind = tokenFloat
+		l.offset++
+		if err := l.readDigits(location); err != nil {
+			return token{}, err
+		}
+	}
+	if l.offset < len(l.source) && (l.source[l.offset] == 'e' || l.source[l.offset] == 'E') {
+		kind = tokenFloat
+		l.offset++
+		if l.offset < len(l.source) && (l.source[l.offset] == '+' || l.source[l.offset] == '-') {
+			l.offset++
+		}
+		if err := l.readDigits(location); err != nil {
+			return token{}, err
+		}
+	}
+	if l.offset < len(l.source) && (l.source[l.offset] == '.' || isNameStart(l.source[l.offset])) {
+		return token{}, l.syntaxError(location, "Invalid number, expected digit but got \""+l.source[l.offset:l.offset+1]+"\".")
+	}
+	return token{kind: kind, value: l.source[start:l.offset], location: location}, nil
+}
+
+func (l *lexer) readDigits(location Location) error {
+	start := l.offset
+	for l.offset < len(l.source) && isDigit(l.source[l.offset]) {
+		l.offset++
+	}
+	if l.offset == start {
+		if l.offset >= len(l.source) {
+			return l.syntaxError(location, "Invalid number, expected digit but got <EOF>.")
+		}
+		return l.syntaxError(location, "Invalid number, expected digit but got \""+l.source[l.offset:l.offset+1]+"\".")
+	}
+	return nil
+}
+
+func (l *lexer) readString(location Location) (token, error) {
+	l.offset++
+	value := strings.Builder{}
+	for l.offset < len(l.source) {
+		char := l.source[l.offset]
+		switch {
+		case char == '"':
+			l.offset++
+			return token{kind: tokenString, value: value.String(), location: location}, nil
+		case char == '\n' || char == '\r':
+			return token{}, l.syntaxError(location, "Unterminated string.")
+		case char == '\\':
+			if l.offset+1 >= len(l.source) {
+				return token{}, l.syntaxError(location, "Unterminated string.")
+			}
+			escaped := l.source[l.offset+1]
+			l.offset += 2
+			switch escaped {
+			case '"', '\\', '/':
+				value.WriteByte(escaped)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				if l.offset+4 > len(l.source) {
+					return token{}, l.syntaxError(location, "Invalid Unicode escape sequence.")
+				}
+				code, err := strconv.ParseUint(l.source[l.offset:l.offset+4], 16, 32)
+				if err != nil {
+					return token{}, l.syntaxError(location, "Invalid Unicode escape sequence: \"\\u"+l.source[l.offset:l.offset+4]+"\".")
+				}
+				value.WriteRune(rune(code))
+				l.offset += 4
+			default:
+				return token{}, l.syntaxError(location, "Invalid character escape sequence: \"\\"+string(escaped)+"\".")
+			}
+		default:
+			value.WriteByte(char)
+			l.offset++
+		}
+	}
+	return token{}, l.syntaxError(location, "Unterminated string.")
+}
+
+func (l *lexer) readBlockString(location Location) (token, error) {
+	l.offset += 3
+	start := l.offset
+	for l.offset < len(l.source) {
+		switch {
+		case strings.HasPrefix(l.source[l.offset:], `"""`):
+			raw := l.source[start:l.offset]
+			l.offset += 3
+			return token{kind: tokenString, value: blockStringValue(strings.ReplaceAll(raw, `\"""`, `"""`)), location: location}, nil
+		case strings.HasPrefix(l.source[l.offset:], `\"""`):
+			l.offset += 4
+		case l.source[l.offset] == '\n':
+			l.offset++
+			l.newline()
+		case l.source[l.offset] == '\r':
+			l.offset++
+			if l.offset < len(l.source) && l.source[l.offset] == '\n' {
+				l.offset++
+			}
+			l.newline()
+		default:
+			l.offset++
+		}
+	}
+	return token{}, l.syntaxError(location, "Unterminated string.")
+}
+
+// blockStringValue removes the common indentation and the leading and trailing blank lines of a block string
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), "\r", "\n"), "\n")
+	commonIndent := -1
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (commonIndent < 0 || indent < commonIndent) {
+			commonIndent = indent
+		}
+	}
+	if commonIndent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= commonIndent {
+				lines[i] = lines[i][commonIndent:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+func isNameContinue(char byte) bool {
+	return isNameStart(char) || isDigit(char)
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
@@ -0,0 +1,530 @@
+// Package fragmentgraphql parses GraphQL operations into struct fragments, so that fragment-aware handlers can serve GraphQL requests.
+// Each root field of an operation is parsed against the Go type registered for it in a schema, where the fields of a selection set
+// are the JSON names or names of the exposed struct fields, see `TypeSDL`, and fragment spreads and inline fragments are merged into the selection sets they are spread in.
+package fragmentgraphql
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	fragment "github.com/ludvigalden/go-fragment"
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// OperationType is the type of a GraphQL operation
+type OperationType string
+
+const (
+	// Query is the type of operations that read data
+	Query OperationType = "query"
+	// Mutation is the type of operations that write data
+	Mutation OperationType = "mutation"
+	// Subscription is the type of operations that receive data as events occur
+	Subscription OperationType = "subscription"
+)
+
+// typeName returns the name of the root type of the operation type, e.g. "Query", which is the type condition of fragments spread on the root
+func (t OperationType) typeName() string {
+	return strings.ToUpper(string(t[:1])) + string(t[1:])
+}
+
+// RootField is a field of a root operation type of a schema
+type RootField struct {
+	// Type is the Go type of the value of the field, e.g. `User{}` or `[]User{}`, against which the selection set of the field is parsed.
+	// If the type is not fragmentable, e.g. a primitive, the field cannot have a selection set.
+	Type interface{}
+	// Arguments is the GraphQL input types of the arguments of the field by name, e.g. `{"id": "ID!"}`, by which the arguments are coerced
+	Arguments map[string]string
+}
+
+// Schema is the root fields of GraphQL operations by operation type and field name
+type Schema struct {
+	Query        map[string]RootField
+	Mutation     map[string]RootField
+	Subscription map[string]RootField
+}
+
+// Request is a GraphQL request, e.g. the JSON body of a POST request to a GraphQL endpoint
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Operation is a parsed GraphQL operation
+type Operation struct {
+	Type OperationType
+	Name string
+	// Variables is the coerced values of the variables of the operation by name, including default values
+	Variables map[string]interface{}
+	// Fields is the selected root fields in the order of the document
+	Fields []Field
+}
+
+// Field is a selected root field of an operation
+type Field struct {
+	// Name is the name of the root field in the schema
+	Name string
+	// Alias is the key of the field in the response, i.e. the alias of the field, or its name if it has no alias
+	Alias string
+	// Arguments is the coerced values of the arguments of the field by name
+	Arguments map[string]interface{}
+	// Fragment is the fragment of the selection set of the field for the type of the root field, which is undefined if the type is not fragmentable
+	Fragment fragment.Struct
+	Location Location
+}
+
+// ParseRequest parses the operation of a GraphQL request, see `Schema.Parse`
+func (s Schema) ParseRequest(request Request) (Operation, error) {
+	return s.Parse(request.Query, request.OperationName, request.Variables)
+}
+
+// Parse parses a GraphQL document and returns the operation with the specified name, or the only operation of the document if the name is empty.
+// The variables, e.g. decoded from the JSON of a request, are coerced to the types of the variable definitions of the operation, and the selection set
+// of each root field is parsed into a struct fragment for the type of the root field, which is validated using `fragment.ParseStruct`.
+// The `@skip` and `@include` directives are supported, and `__typename` fields are ignored.
+// The returned error is of type `Errors`, which can be written as the `errors` of a GraphQL response.
+func (s Schema) Parse(document string, operationName string, variables map[string]interface{}) (Operation, error) {
+	doc, err := parseDocument(document)
+	if err != nil {
+		return Operation{}, errorsOf(err)
+	}
+	operationDefinition, err := doc.operation(operationName)
+	if err != nil {
+		return Operation{}, errorsOf(err)
+	}
+	ctx := &parseContext{
+		schema:        s,
+		document:      doc,
+		operation:     operationDefinition,
+		variables:     map[string]interface{}{},
+		usedVariables: map[string]bool{},
+		usedFragments: map[string]bool{},
+	}
+	ctx.coerceVariables(variables)
+	if len(ctx.errs) > 0 {
+		return Operation{}, ctx.errs
+	}
+	operation := Operation{Type: operationDefinition.operationType, Name: operationDefinition.name, Variables: ctx.variables}
+	rootFields := ctx.rootFields()
+	if rootFields == nil {
+		return Operation{}, Errors{{Message: "Schema is not configured for " + string(operation.Type) + " operations.", Locations: []Location{operationDefinition.location}}}
+	}
+	if _, err := ctx.directivesInclude(operationDefinition.directives); err != nil {
+		ctx.errs = append(ctx.errs, errorsOf(err)...)
+	}
+	fieldsByAlias := map[string]int{}
+	for _, selection := range ctx.collectRootSelections(operationDefinition.selectionSet, map[string]bool{}) {
+		field, ok := ctx.parseRootField(rootFields, selection)
+		if !ok {
+			continue
+		}
+		if i, ok := fieldsByAlias[field.Alias]; ok {
+			if operation.Fields[i].Name != field.Name {
+				ctx.addError("Fields \""+field.Alias+"\" conflict because \""+operation.Fields[i].Name+"\" and \""+field.Name+"\" are different fields.", []interface{}{field.Alias}, operation.Fields[i].Location, field.Location)
+				continue
+			} else if !reflect.DeepEqual(operation.Fields[i].Arguments, field.Arguments) {
+				ctx.addError("Fields \""+field.Alias+"\" conflict because they have differing arguments.", []interface{}{field.Alias}, operation.Fields[i].Location, field.Location)
+				continue
+			}
+			operation.Fields[i].Fragment = operation.Fields[i].Fragment.AssignStruct(field.Fragment)
+			continue
+		}
+		fieldsByAlias[field.Alias] = len(operation.Fields)
+		operation.Fields = append(operation.Fields, field)
+	}
+	ctx.checkUnused()
+	if len(ctx.errs) > 0 {
+		return Operation{}, ctx.errs
+	}
+	return operation, nil
+}
+
+// operation returns the operation with the specified name, or the only operation of the document if the name is empty
+func (d *document) operation(name string) (*operationDefinition, error) {
+	if name == "" {
+		if len(d.operations) != 1 {
+			return nil, Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return d.operations[0], nil
+	}
+	for _, operation := range d.operations {
+		if operation.name == name {
+			return operation, nil
+		}
+	}
+	return nil, Error{Message: "Unknown operation named \"" + name + "\"."}
+}
+
+// parseContext is the state of parsing an operation of a document
+type parseContext struct {
+	schema        Schema
+	document      *document
+	operation     *operationDefinition
+	variables     map[string]interface{}
+	usedVariables map[string]bool
+	usedFragments map[string]bool
+	errs          Errors
+}
+
+func (ctx *parseContext) addError(message string, path []interface{}, locations ...Location) {
+	ctx.errs = append(ctx.errs, Error{Message: message, Locations: locations, Path: path})
+}
+
+func (ctx *parseContext) rootFields() map[string]RootField {
+	switch ctx.operation.operationType {
+	case Mutation:
+		return ctx.schema.Mutation
+	case Subscription:
+		return ctx.schema.Subscription
+	}
+	return ctx.schema.Query
+}
+
+// coerceVariables coerces the values of the variables of the operation to the types of their definitions, using default values for missing variables
+func (ctx *parseContext) coerceVariables(values map[string]interface{}) {
+	for _, definition := range ctx.operation.variables {
+		if _, ok := ctx.variables[definition.name]; ok {
+			ctx.addError("There can be only one variable named \"$"+definition.name+"\".", nil, definition.location)
+			continue
+		}
+		value, ok := values[definition.name]
+		if !ok {
+			if definition.defaultValue != nil {
+				defaultValue, err := ctx.literalValue(definition.defaultValue)
+				if err == nil {
+					defaultValue, err = coerceValue(defaultValue, definition.typeRef)
+				}
+				if err != nil {
+					ctx.addError("Variable \"$"+definition.name+"\" has invalid default value: "+err.Error(), nil, definition.location)
+				}
+				ctx.variables[definition.name] = defaultValue
+				continue
+			} else if definition.typeRef.nonNull {
+				ctx.addError("Variable \"$"+definition.name+"\" of required type \""+definition.typeRef.String()+"\" was not provided.", nil, definition.location)
+			}
+			ctx.variables[definition.name] = nil
+			continue
+		}
+		if value == nil && definition.typeRef.nonNull {
+			ctx.addError("Variable \"$"+definition.name+"\" of non-null type \""+definition.typeRef.String()+"\" must not be null.", nil, definition.location)
+			continue
+		}
+		coerced, err := coerceValue(value, definition.typeRef)
+		if err != nil {
+			ctx.addError("Variable \"$"+definition.name+"\" got invalid value "+valueString(value)+"; "+err.Error(), nil, definition.location)
+			continue
+		}
+		ctx.variables[definition.name] = coerced
+	}
+}
+
+// literalValue returns the Go value of a value of the document, where variables are replaced by their coerced values.
+// Integers are returned as int64, floats as float64, enums as strings, and objects as `map[string]interface{}`.
+func (ctx *parseContext) literalValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case variableValue:
+		variable, ok := ctx.variables[string(value)]
+		if !ok {
+			return nil, Error{Message: "Variable \"$" + string(value) + "\" is not defined" + ctx.operationSuffix() + "."}
+		}
+		ctx.usedVariables[string(value)] = true
+		return variable, nil
+	case intValue:
+		i, ok := parseIntLiteral(string(value))
+		if !ok {
+			return nil, Error{Message: "Int cannot represent non 64-bit signed integer value: " + string(value)}
+		}
+		return i, nil
+	case floatValue:
+		f, _ := parseFloatLiteral(string(value))
+		return f, nil
+	case enumValue:
+		return string(value), nil
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, elem := range value {
+			elemValue, err := ctx.literalValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = elemValue
+		}
+		return list, nil
+	case objectValue:
+		object := map[string]interface{}{}
+		for _, field := range value {
+			fieldValue, err := ctx.literalValue(field.value)
+			if err != nil {
+				return nil, err
+			}
+			object[field.name] = fieldValue
+		}
+		return object, nil
+	}
+	return value, nil
+}
+
+func (ctx *parseContext) operationSuffix() string {
+	if ctx.operation.name == "" {
+		return ""
+	}
+	return " by operation \"" + ctx.operation.name + "\""
+}
+
+// directivesInclude returns whether a selection with the directives is included according to its `@skip` and `@include` directives
+func (ctx *parseContext) directivesInclude(directives []directive) (bool, error) {
+	include := true
+	for _, directive := range directives {
+		if directive.name != "skip" && directive.name != "include" {
+			return false, Error{Message: "Unknown directive \"@" + directive.name + "\".", Locations: []Location{directive.location}}
+		}
+		var condition interface{}
+		var conditionFound bool
+		for _, argument := range directive.arguments {
+			if argument.name != "if" {
+				return false, Error{Message: "Unknown argument \"" + argument.name + "\" on directive \"@" + directive.name + "\".", Locations: []Location{argument.location}}
+			}
+			value, err := ctx.literalValue(argument.value)
+			if err != nil {
+				return false, Error{Message: err.Error(), Locations: []Location{argument.location}}
+			}
+			condition, conditionFound = value, true
+		}
+		conditionValue, ok := condition.(bool)
+		if !conditionFound || !ok {
+			return false, Error{Message: "Directive \"@" + directive.name + "\" argument \"if\" of type \"Boolean!\" is required, but it was not provided.", Locations: []Location{directive.location}}
+		}
+		if (directive.name == "skip" && conditionValue) || (directive.name == "include" && !conditionValue) {
+			include = false
+		}
+	}
+	return include, nil
+}
+
+// collectRootSelections returns the included root field selections of a selection set, where fragments spread on the root type are flattened
+func (ctx *parseContext) collectRootSelections(selections []selection, spread map[string]bool) []selection {
+	rootTypeName := ctx.operation.operationType.typeName()
+	fields := []selection{}
+	for _, selection := range selections {
+		if include, err := ctx.directivesInclude(selection.directives); err != nil {
+			ctx.errs = append(ctx.errs, errorsOf(err)...)
+			continue
+		} else if !include {
+			continue
+		}
+		switch selection.kind {
+		case fieldSelection:
+			fields = append(fields, selection)
+		case inlineFragmentSelection:
+			if selection.typeCondition != "" && selection.typeCondition != rootTypeName {
+				ctx.addError("Fragment cannot be spread here as objects of type \""+rootTypeName+"\" can never be of type \""+selection.typeCondition+"\".", nil, selection.location)
+				continue
+			}
+			fields = append(fields, ctx.collectRootSelections(selection.selectionSet, spread)...)
+		case fragmentSpreadSelection:
+			fragmentDefinition, ok := ctx.spreadFragment(selection, rootTypeName, spread, nil)
+			if !ok {
+				continue
+			}
+			spread[selection.name] = true
+			fields = append(fields, ctx.collectRootSelections(fragmentDefinition.selectionSet, spread)...)
+			delete(spread, selection.name)
+		}
+	}
+	return fields
+}
+
+// spreadFragment returns the definition of a spread fragment, validating that it exists, is not spread within itself, and matches the type
+func (ctx *parseContext) spreadFragment(selection selection, typeName string, spread map[string]bool, path []interface{}) (*fragmentDefinition, bool) {
+	fragmentDefinition, ok := ctx.document.fragments[selection.name]
+	if !ok {
+		ctx.addError("Unknown fragment \""+selection.name+"\".", path, selection.location)
+		return nil, false
+	}
+	ctx.usedFragments[selection.name] = true
+	if spread[selection.name] {
+		ctx.addError("Cannot spread fragment \""+selection.name+"\" within itself.", path, selection.location)
+		return nil, false
+	} else if fragmentDefinition.typeCondition != typeName {
+		ctx.addError("Fragment \""+selection.name+"\" cannot be spread here as objects of type \""+typeName+"\" can never be of type \""+fragmentDefinition.typeCondition+"\".", path, selection.location)
+		return nil, false
+	}
+	if include, err := ctx.directivesInclude(fragmentDefinition.directives); err != nil {
+		ctx.errs = append(ctx.errs, errorsOf(err)...)
+		return nil, false
+	} else if !include {
+		return nil, false
+	}
+	return fragmentDefinition, true
+}
+
+// parseRootField parses a root field selection, coercing its arguments and parsing its selection set against the type of the root field
+func (ctx *parseContext) parseRootField(rootFields map[string]RootField, selection selection) (Field, bool) {
+	field := Field{Name: selection.name, Alias: selection.responseKey(), Arguments: map[string]interface{}{}, Location: selection.location}
+	path := []interface{}{field.Alias}
+	if selection.name == "__typename" {
+		return field, false
+	}
+	rootField, ok := rootFields[selection.name]
+	if !ok {
+		ctx.addError("Cannot query field \""+selection.name+"\" on type \""+ctx.operation.operationType.typeName()+"\".", path, selection.location)
+		return field, false
+	}
+	errsLen := len(ctx.errs)
+	ctx.coerceArguments(rootField, selection, field.Arguments, path)
+	structTypeMeta := typemeta.StructOf(typemeta.Get(rootField.Type))
+	if !fragment.IsFragmentable(rootField.Type) {
+		if selection.selectionSet != nil {
+			ctx.addError("Field \""+selection.name+"\" must not have a selection since type \""+typemeta.Get(rootField.Type).String()+"\" has no subfields.", path, selection.location)
+		}
+	} else if selection.selectionSet == nil {
+		ctx.addError("Field \""+selection.name+"\" of type \""+structTypeMeta.Name()+"\" must have a selection of subfields. Did you mean \""+selection.name+" { ... }\"?", path, selection.location)
+	} else {
+		unstructured := ctx.parseSelectionSet(structTypeMeta, selection.selectionSet, path, map[string]bool{})
+		if len(ctx.errs) == errsLen {
+			structFragment, err := fragment.ParseStruct(rootField.Type, unstructured)
+			if err != nil {
+				ctx.addError(err.Error(), path, selection.location)
+			}
+			field.Fragment = structFragment
+		}
+	}
+	return field, len(ctx.errs) == errsLen
+}
+
+// coerceArguments coerces the arguments of a root field selection to the types of the arguments of the root field
+func (ctx *parseContext) coerceArguments(rootField RootField, selection selection, arguments map[string]interface{}, path []interface{}) {
+	fieldName := ctx.operation.operationType.typeName() + "." + selection.name
+	types := map[string]*typeRef{}
+	for name, typeExpr := range rootField.Arguments {
+		t, err := parseTypeRefExpr(typeExpr)
+		if err != nil {
+			ctx.addError("Invalid type \""+typeExpr+"\" of argument \""+name+"\" of field \""+fieldName+"\": "+err.Error(), path, selection.location)
+			return
+		}
+		types[name] = t
+	}
+	for _, argument := range selection.arguments {
+		t, ok := types[argument.name]
+		if !ok {
+			ctx.addError("Unknown argument \""+argument.name+"\" on field \""+fieldName+"\".", path, argument.location)
+			continue
+		} else if _, ok := arguments[argument.name]; ok {
+			ctx.addError("There can be only one argument named \""+argument.name+"\".", path, argument.location)
+			continue
+		}
+		value, err := ctx.literalValue(argument.value)
+		if err != nil {
+			ctx.addError(err.Error(), path, argument.location)
+			arguments[argument.name] = nil
+			continue
+		}
+		value, err = coerceValue(value, t)
+		if err != nil {
+			ctx.addError("Argument \""+argument.name+"\" of field \""+fieldName+"\" has invalid value: "+err.Error(), path, argument.location)
+			continue
+		}
+		arguments[argument.name] = value
+	}
+	for name, t := range types {
+		if _, ok := arguments[name]; !ok && t.nonNull {
+			ctx.addError("Field \""+fieldName+"\" argument \""+name+"\" of type \""+t.String()+"\" is required, but it was not provided.", path, selection.location)
+		}
+	}
+}
+
+// parseSelectionSet returns the unstructured fragment of a selection set of a struct type, merging fields that are selected more than once
+func (ctx *parseContext) parseSelectionSet(structTypeMeta *typemeta.Struct, selections []selection, path []interface{}, spread map[string]bool) fragment.Unstructured {
+	unstructured := fragment.NewEmptyUnstructured()
+	typeName := structTypeMeta.Name()
+	for _, selection := range selections {
+		if include, err := ctx.directivesInclude(selection.directives); err != nil {
+			ctx.errs = append(ctx.errs, errorsOf(err)...)
+			continue
+		} else if !include {
+			continue
+		}
+		switch selection.kind {
+		case inlineFragmentSelection:
+			if selection.typeCondition != "" && selection.typeCondition != typeName {
+				ctx.addError("Fragment cannot be spread here as objects of type \""+typeName+"\" can never be of type \""+selection.typeCondition+"\".", path, selection.location)
+				continue
+			}
+			unstructured = unstructured.Assign(ctx.parseSelectionSet(structTypeMeta, selection.selectionSet, path, spread))
+		case fragmentSpreadSelection:
+			fragmentDefinition, ok := ctx.spreadFragment(selection, typeName, spread, path)
+			if !ok {
+				continue
+			}
+			spread[selection.name] = true
+			unstructured = unstructured.Assign(ctx.parseSelectionSet(structTypeMeta, fragmentDefinition.selectionSet, path, spread))
+			delete(spread, selection.name)
+		case fieldSelection:
+			if selection.name == "__typename" {
+				continue
+			}
+			fieldPath := append(append([]interface{}{}, path...), selection.responseKey())
+			structField := structTypeMeta.FieldByName(selection.name)
+			if structField == nil || !exposed(*structField) {
+				ctx.addError("Cannot query field \""+selection.name+"\" on type \""+typeName+"\".", fieldPath, selection.location)
+				continue
+			}
+			for _, argument := range selection.arguments {
+				ctx.addError("Unknown argument \""+argument.name+"\" on field \""+typeName+"."+selection.name+"\".", fieldPath, argument.location)
+			}
+			fieldStructTypeMeta := typemeta.StructOf(structField.TypeMeta)
+			if !fragment.IsFragmentable(structField.TypeMeta) {
+				if selection.selectionSet != nil {
+					ctx.addError("Field \""+selection.name+"\" must not have a selection since type \""+structField.TypeMeta.String()+"\" has no subfields.", fieldPath, selection.location)
+					continue
+				}
+				unstructured = unstructured.Assign(fragment.NewEmptyUnstructured().Add(selection.name))
+			} else if selection.selectionSet == nil {
+				ctx.addError("Field \""+selection.name+"\" of type \""+fieldStructTypeMeta.Name()+"\" must have a selection of subfields. Did you mean \""+selection.name+" { ... }\"?", fieldPath, selection.location)
+			} else {
+				fieldFragment := ctx.parseSelectionSet(fieldStructTypeMeta, selection.selectionSet, fieldPath, spread)
+				unstructured = unstructured.Assign(fragment.NewEmptyUnstructured().Set(selection.name, fieldFragment))
+			}
+		}
+	}
+	return unstructured
+}
+
+// checkUnused adds errors for the fragments and variables that are not used by the operation
+func (ctx *parseContext) checkUnused() {
+	for _, definition := range ctx.operation.variables {
+		if !ctx.usedVariables[definition.name] {
+			ctx.addError("Variable \"$"+definition.name+"\" is never used"+strings.Replace(ctx.operationSuffix(), " by ", " in ", 1)+".", nil, definition.location)
+		}
+	}
+	if len(ctx.document.operations) > 1 {
+		return
+	}
+	names := make([]string, 0, len(ctx.document.fragments))
+	for name := range ctx.document.fragments {
+		if !ctx.usedFragments[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ctx.addError("Fragment \""+name+"\" is never used.", nil, ctx.document.fragments[name].location)
+	}
+}
+
+// parseTypeRefExpr parses a GraphQL input type expression, e.g. `[ID!]!`
+func parseTypeRefExpr(expr string) (*typeRef, error) {
+	p := &parser{lexer: newLexer(expr)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	t, err := p.parseTypeRef()
+	if err != nil {
+		return nil, err
+	} else if p.token.kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return t, nil
+}
@@ -0,0 +1,117 @@
+package fragmentgraphql
+
+import (
+	"encoding/json"
+	"testing"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+func TestParse(t *testing.T) {
+	type Profile struct {
+		Bio     string `json:"bio"`
+		Website string `json:"website"`
+	}
+	type Post struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	type User struct {
+		ID      string   `json:"id"`
+		Name    string   `json:"name"`
+		Email   string   `json:"email"`
+		Profile *Profile `json:"profile"`
+		Posts   []Post   `json:"posts"`
+	}
+	schema := Schema{
+		Query: map[string]RootField{
+			"user":  {Type: User{}, Arguments: map[string]string{"id": "ID!"}},
+			"users": {Type: []User{}, Arguments: map[string]string{"first": "Int", "ids": "[ID!]"}},
+			"count": {Type: 0},
+		},
+	}
+	t.Run("parses operation with variables and fragments", func(t *testing.T) {
+		operation, err := schema.Parse(`
+			query Q($id: ID!, $withProfile: Boolean = true) {
+				user(id: $id) {
+					name
+					...F
+					profile @include(if: $withProfile) { bio }
+					__typename
+				}
+				others: users(first: 2, ids: 3) { ... on User { posts { title } } }
+				count
+			}
+			fragment F on User { email, posts { id } }
+		`, "", map[string]interface{}{"id": float64(1)})
+		if err != nil {
+			t.Error("did not expect `Parse` to return error: " + err.Error())
+			return
+		}
+		if operation.Type != Query || operation.Name != "Q" || len(operation.Fields) != 3 {
+			t.Error("unexpected operation")
+			return
+		}
+		user := operation.Fields[0]
+		if user.Name != "user" || user.Alias != "user" || user.Arguments["id"] != "1" || operation.Variables["withProfile"] != true {
+			t.Error("expected variables to be coerced")
+		}
+		expected, _ := fragment.ParseStruct(User{}, "name, email, profile { bio }, posts { id }")
+		if user.Fragment.Expr() != expected.Expr() {
+			t.Error("unexpected fragment " + user.Fragment.Expr())
+		}
+		others := operation.Fields[1]
+		if others.Name != "users" || others.Alias != "others" || others.Arguments["first"] != 2 || len(others.Arguments["ids"].([]interface{})) != 1 {
+			t.Error("expected literal arguments to be coerced")
+		}
+		if others.Fragment.JSONExpr() != "{ posts { title } }" {
+			t.Error("unexpected fragment " + others.Fragment.JSONExpr())
+		}
+		if operation.Fields[2].Name != "count" || !operation.Fields[2].Fragment.IsUndefined() {
+			t.Error("expected leaf root field")
+		}
+	})
+	t.Run("selects operation by name and skips fields", func(t *testing.T) {
+		operation, err := schema.Parse(`
+			query A { count }
+			query B($skip: Boolean!) { user(id: "1") { name, email @skip(if: $skip) } }
+		`, "B", map[string]interface{}{"skip": true})
+		if err != nil {
+			t.Error("did not expect `Parse` to return error: " + err.Error())
+			return
+		}
+		if len(operation.Fields) != 1 || operation.Fields[0].Fragment.JSONExpr() != "{ name }" {
+			t.Error("unexpected fields of operation")
+		}
+	})
+	t.Run("returns errors in GraphQL format", func(t *testing.T) {
+		_, err := schema.Parse("{\n  user(id: 1) {\n    nmae\n    profile\n  }\n}", "", nil)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 2 {
+			t.Error("expected `Parse` to return two errors")
+			return
+		}
+		bytes, _ := json.Marshal(Response{Errors: errs})
+		if body := string(bytes); body != `{"errors":[{"message":"Cannot query field \"nmae\" on type \"User\".","locations":[{"line":3,"column":5}],"path":["user","nmae"]},`+
+			`{"message":"Field \"profile\" of type \"Profile\" must have a selection of subfields. Did you mean \"profile { ... }\"?","locations":[{"line":4,"column":5}],"path":["user","profile"]}]}` {
+			t.Error("unexpected errors " + body)
+		}
+	})
+	t.Run("returns errors of variables", func(t *testing.T) {
+		for document, variables := range map[string]map[string]interface{}{
+			`query ($id: ID!) { user(id: $id) { name } }`:           {},
+			`query ($first: Int) { users(first: $first) { name } }`: {"first": 1.5},
+			`query ($id: ID!) { user(id: $other) { name } }`:        {"id": "1"},
+			`{ user { name } }`:                nil,
+			`{ user(id: "1") { name } `:        nil,
+			`{ user(id: "1") { ...Missing } }`: nil,
+		} {
+			if _, err := schema.Parse(document, "", variables); err == nil {
+				t.Error("expected `Parse` to return error for " + document)
+			} else if _, ok := err.(Errors); !ok {
+				t.Error("expected `Parse` to return errors for " + document)
+			}
+		}
+	})
+}
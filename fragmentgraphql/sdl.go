@@ -0,0 +1,316 @@
+package fragmentgraphql
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	fragment "github.com/ludvigalden/go-fragment"
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// SDL returns the GraphQL schema definition language of the schema, i.e. the root operation types with the arguments and types of their fields,
+// followed by the definitions of all types reachable from the root fields, see `TypeSDL`.
+func (s Schema) SDL() (string, error) {
+	b := newSDLBuilder()
+	rootFieldsByOperationType := map[OperationType]map[string]RootField{Query: s.Query, Mutation: s.Mutation, Subscription: s.Subscription}
+	// the types of all root fields are defined first, so that arguments can be of the enums and scalars they define
+	for _, rootFields := range rootFieldsByOperationType {
+		for _, rootField := range rootFields {
+			if _, err := b.typeExpr(typemeta.Get(rootField.Type), false, false); err != nil {
+				return "", err
+			}
+		}
+	}
+	definitions := []string{}
+	for _, operationType := range []OperationType{Query, Mutation, Subscription} {
+		rootFields := rootFieldsByOperationType[operationType]
+		if len(rootFields) == 0 {
+			continue
+		}
+		definition, err := b.rootTypeDefinition(operationType, rootFields)
+		if err != nil {
+			return "", err
+		}
+		definitions = append(definitions, definition)
+	}
+	return strings.Join(append(definitions, b.definitions()...), "\n\n") + "\n", nil
+}
+
+// TypeSDL returns the GraphQL SDL type definitions of the specified types and of all types reachable through their fields, sorted by name.
+// Structs are defined as object types named by their type meta, and the fields of them are named by their JSON names, where fields that are private,
+// excluded from JSON, or tagged with `fragment:"-"` are not exposed. Fields that `fragment.PickJSON` may present as null using
+// `fragment.DefaultPolicy` are nullable, see `fragment.FieldCanBeJSONNull`, e.g. pointers, strings, structs, slices, and maps, and numbers and booleans
+// with the `omitempty` option, and slices and arrays are lists. Strings, booleans, integers, and floats are mapped to the
+// built-in scalars, where fields with the JSON name "id" are `ID`, primitives with enums are defined as enum types, types implementing `json.Marshaler`
+// are defined as custom scalars named by their type, and maps and interfaces are the custom scalar `JSON`. Descriptions of types and fields are included.
+func TypeSDL(t ...interface{}) (string, error) {
+	b := newSDLBuilder()
+	for _, t := range t {
+		if _, err := b.typeExpr(typemeta.Get(t), false, false); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(b.definitions(), "\n\n") + "\n", nil
+}
+
+// sdlBuilder collects the definitions of the types reachable from the types it is passed
+type sdlBuilder struct {
+	definitionsByName map[string]string
+	typesByName       map[string]reflect.Type
+}
+
+func newSDLBuilder() *sdlBuilder {
+	return &sdlBuilder{definitionsByName: map[string]string{}, typesByName: map[string]reflect.Type{}}
+}
+
+func (b *sdlBuilder) definitions() []string {
+	names := make([]string, 0, len(b.definitionsByName))
+	for name := range b.definitionsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	definitions := make([]string, len(names))
+	for i, name := range names {
+		definitions[i] = b.definitionsByName[name]
+	}
+	return definitions
+}
+
+// define reserves the name of a type definition, returning false if the type is already defined, and an error if another type has the same name
+func (b *sdlBuilder) define(name string, t reflect.Type) (bool, error) {
+	if !graphQLNameRegexp.MatchString(name) {
+		return false, errors.New("cannot define GraphQL type for " + t.String() + " with invalid name \"" + name + "\"")
+	} else if definedType, ok := b.typesByName[name]; ok {
+		if definedType != t {
+			return false, errors.New("cannot define GraphQL type \"" + name + "\" for both " + definedType.String() + " and " + t.String())
+		}
+		return false, nil
+	} else if builtInScalars[name] {
+		return false, errors.New("cannot define GraphQL type for " + t.String() + " with the name of the built-in scalar \"" + name + "\"")
+	}
+	b.typesByName[name] = t
+	b.definitionsByName[name] = ""
+	return true, nil
+}
+
+func (b *sdlBuilder) rootTypeDefinition(operationType OperationType, rootFields map[string]RootField) (string, error) {
+	names := make([]string, 0, len(rootFields))
+	for name := range rootFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := []string{"type " + operationType.typeName() + " {"}
+	for _, name := range names {
+		if !graphQLNameRegexp.MatchString(name) {
+			return "", errors.New("invalid name of root field \"" + name + "\"")
+		}
+		rootField := rootFields[name]
+		arguments, err := b.argumentsExpr(rootField.Arguments)
+		if err != nil {
+			return "", errors.New("invalid arguments of root field \"" + name + "\": " + err.Error())
+		}
+		typeMeta := typemeta.Get(rootField.Type)
+		fieldType, err := b.typeExpr(typeMeta, !fragment.TypeCanBeJSONNull(typeMeta.Type()), false)
+		if err != nil {
+			return "", errors.New("invalid type of root field \"" + name + "\": " + err.Error())
+		}
+		lines = append(lines, "  "+name+arguments+": "+fieldType)
+	}
+	return strings.Join(append(lines, "}"), "\n"), nil
+}
+
+// argumentsExpr returns the arguments definition of a field, e.g. `(first: Int, id: ID!)`, where the types must be built-in scalars or defined types
+func (b *sdlBuilder) argumentsExpr(arguments map[string]string) (string, error) {
+	if len(arguments) == 0 {
+		return "", nil
+	}
+	names := make([]string, 0, len(arguments))
+	for name := range arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	exprs := make([]string, len(names))
+	for i, name := range names {
+		t, err := parseTypeRefExpr(arguments[name])
+		if err != nil {
+			return "", err
+		}
+		namedType := t
+		for namedType.elem != nil {
+			namedType = namedType.elem
+		}
+		if _, ok := b.typesByName[namedType.name]; !ok && !builtInScalars[namedType.name] {
+			return "", errors.New("unknown type \"" + namedType.name + "\" of argument \"" + name + "\"")
+		}
+		exprs[i] = name + ": " + t.String()
+	}
+	return "(" + strings.Join(exprs, ", ") + ")", nil
+}
+
+// typeExpr returns the GraphQL type of a Go type, e.g. `[User!]`, defining the types it references. If id is true, strings and integers are `ID`.
+func (b *sdlBuilder) typeExpr(typeMeta typemeta.TypeMeta, nonNull bool, id bool) (string, error) {
+	typeMeta = typemeta.NonPtr(typeMeta)
+	suffix := ""
+	if nonNull {
+		suffix = "!"
+	}
+	switch t := typeMeta.(type) {
+	case *typemeta.Slice, *typemeta.Array:
+		elem := typemeta.ElemOf(t)
+		if elem.Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return "String" + suffix, nil
+		}
+		elemExpr, err := b.typeExpr(elem, !fragment.TypeCanBeJSONNull(elem.Type()), id)
+		if err != nil {
+			return "", err
+		}
+		return "[" + elemExpr + "]" + suffix, nil
+	case *typemeta.Map, *typemeta.Interface:
+		if _, err := b.define("JSON", jsonScalarType); err != nil {
+			return "", err
+		}
+		b.definitionsByName["JSON"] = "scalar JSON"
+		return "JSON" + suffix, nil
+	case *typemeta.Struct:
+		if valueTypeMeta, ok := fragment.NullableValueTypeMeta(t); ok && valueTypeMeta != nil {
+			return b.typeExpr(valueTypeMeta, false, id)
+		} else if ok {
+			return b.scalar(t.Name(), t.Type())
+		}
+		if err := b.defineObject(t); err != nil {
+			return "", err
+		}
+		return t.Name() + suffix, nil
+	case *typemeta.Primitive:
+		name, err := b.primitiveName(t, id)
+		if err != nil {
+			return "", err
+		}
+		return name + suffix, nil
+	}
+	return "", errors.New("cannot map type " + typeMeta.String() + " to a GraphQL type")
+}
+
+func (b *sdlBuilder) primitiveName(primitive *typemeta.Primitive, id bool) (string, error) {
+	if enum := primitive.Enum(); enum != nil {
+		return b.defineEnum(primitive, enum)
+	}
+	switch primitive.Kind() {
+	case reflect.Bool:
+		return "Boolean", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if id {
+			return "ID", nil
+		}
+		return "Int", nil
+	case reflect.Float32, reflect.Float64:
+		return "Float", nil
+	case reflect.String:
+		if id {
+			return "ID", nil
+		}
+		return "String", nil
+	case reflect.Struct:
+		return b.scalar(primitive.Name(), primitive.Type())
+	}
+	return "", errors.New("cannot map type " + primitive.String() + " to a GraphQL type")
+}
+
+func (b *sdlBuilder) scalar(name string, t reflect.Type) (string, error) {
+	if ok, err := b.define(name, t); err != nil {
+		return "", err
+	} else if ok {
+		b.definitionsByName[name] = "scalar " + name
+	}
+	return name, nil
+}
+
+func (b *sdlBuilder) defineEnum(primitive *typemeta.Primitive, enum *typemeta.Enum) (string, error) {
+	name := primitive.Name()
+	if ok, err := b.define(name, primitive.Type()); err != nil || !ok {
+		return name, err
+	}
+	values := []string{}
+	var valueErr error
+	enum.IterateValues(func(value string, _ interface{}) {
+		if !graphQLNameRegexp.MatchString(value) || value == "true" || value == "false" || value == "null" {
+			valueErr = errors.New("invalid value \"" + value + "\" of enum " + name)
+		}
+		values = append(values, "  "+value)
+	})
+	if valueErr != nil {
+		return "", valueErr
+	}
+	sort.Strings(values)
+	b.definitionsByName[name] = "enum " + name + " {\n" + strings.Join(values, "\n") + "\n}"
+	return name, nil
+}
+
+func (b *sdlBuilder) defineObject(structTypeMeta *typemeta.Struct) error {
+	name := structTypeMeta.Name()
+	if ok, err := b.define(name, structTypeMeta.Type()); err != nil || !ok {
+		return err
+	}
+	lines := []string{}
+	if structTypeMeta.Description != "" {
+		lines = append(lines, descriptionExpr(structTypeMeta.Description, ""))
+	}
+	lines = append(lines, "type "+name+" {")
+	var fieldErr error
+	structTypeMeta.IterateFields(func(structField typemeta.StructField) {
+		if fieldErr != nil || !exposed(structField) {
+			return
+		}
+		if !graphQLNameRegexp.MatchString(structField.JSONName) {
+			fieldErr = errors.New("invalid GraphQL name of field \"" + structField.String() + "\" of " + name)
+			return
+		}
+		id := structField.JSONName == "id" || structField.JSONName == "ID"
+		fieldType, err := b.typeExpr(structField.TypeMeta, !fragment.FieldCanBeJSONNull(structField), id)
+		if err != nil {
+			fieldErr = errors.New("invalid type of field \"" + structField.Name + "\" of " + name + ": " + err.Error())
+			return
+		}
+		if structField.Description != "" {
+			lines = append(lines, descriptionExpr(structField.Description, "  "))
+		}
+		lines = append(lines, "  "+structField.JSONName+": "+fieldType)
+	})
+	if fieldErr != nil {
+		return fieldErr
+	} else if len(lines) == 1 || (structTypeMeta.Description != "" && len(lines) == 2) {
+		return errors.New("cannot define GraphQL type " + name + " without exposed fields")
+	}
+	b.definitionsByName[name] = strings.Join(append(lines, "}"), "\n")
+	return nil
+}
+
+// descriptionExpr returns a description of a definition, which is a block string if it spans multiple lines or contains quotes
+func descriptionExpr(description string, indent string) string {
+	if !strings.ContainsAny(description, "\"\\\n") {
+		return indent + "\"" + description + "\""
+	}
+	lines := strings.Split(strings.ReplaceAll(description, `"""`, `\"""`), "\n")
+	for i, line := range lines {
+		lines[i] = indent + line
+	}
+	return indent + `"""` + "\n" + strings.Join(lines, "\n") + "\n" + indent + `"""`
+}
+
+// exposed returns whether a struct field is exposed in GraphQL, i.e. whether it is public, included in JSON, and not tagged with `fragment:"-"`
+func exposed(structField typemeta.StructField) bool {
+	if structField.Private || structField.JSONExcluded {
+		return false
+	}
+	fragmentTag := structField.Tag("fragment")
+	return fragmentTag == nil || fragmentTag.Name != "-"
+}
+
+var graphQLNameRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+var builtInScalars = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+var jsonScalarType = reflect.TypeOf((*interface{})(nil)).Elem()
@@ -0,0 +1,109 @@
+package fragmentgraphql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+type sdlTestRole string
+
+func TestSDL(t *testing.T) {
+	typemeta.Get(sdlTestRole("")).(*typemeta.Primitive).SetName("Role").SetEnum(typemeta.NewEnum("Role", []string{"member", "admin"}))
+	type Profile struct {
+		Bio      string         `json:"bio" description:"Biography of the user"`
+		Nickname sql.NullString `json:"nickname"`
+	}
+	type Post struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+	}
+	type User struct {
+		ID        string                 `json:"id"`
+		Name      string                 `json:"name"`
+		Email     string                 `json:"email,omitempty"`
+		Role      sdlTestRole            `json:"role"`
+		Profile   *Profile               `json:"profile"`
+		Posts     []Post                 `json:"posts"`
+		Scores    [3]float64             `json:"scores"`
+		Metadata  map[string]interface{} `json:"metadata"`
+		CreatedAt time.Time              `json:"createdAt"`
+		Password  string                 `json:"-"`
+		Internal  string                 `json:"internal" fragment:"-"`
+		secret    string
+	}
+	t.Run("generates type definitions", func(t *testing.T) {
+		sdl, err := TypeSDL(User{})
+		if err != nil {
+			t.Error("did not expect `TypeSDL` to return error: " + err.Error())
+			return
+		}
+		expected := `scalar JSON
+
+type Post {
+  id: ID!
+  title: String
+}
+
+type Profile {
+  "Biography of the user"
+  bio: String
+  nickname: String
+}
+
+enum Role {
+  admin
+  member
+}
+
+scalar Time
+
+type User {
+  id: ID
+  name: String
+  email: String
+  role: Role
+  profile: Profile
+  posts: [Post!]
+  scores: [Float!]!
+  metadata: JSON
+  createdAt: Time
+}
+`
+		if sdl != expected {
+			t.Error("unexpected SDL " + sdl)
+		}
+	})
+	t.Run("generates schema", func(t *testing.T) {
+		schema := Schema{
+			Query: map[string]RootField{
+				"user":  {Type: &User{}, Arguments: map[string]string{"id": "ID!"}},
+				"users": {Type: []User{}, Arguments: map[string]string{"role": "Role", "first": "Int"}},
+			},
+			Mutation: map[string]RootField{"deletePost": {Type: true, Arguments: map[string]string{"id": "ID!"}}},
+		}
+		sdl, err := schema.SDL()
+		if err != nil {
+			t.Error("did not expect `SDL` to return error: " + err.Error())
+			return
+		}
+		expected := "type Query {\n  user(id: ID!): User\n  users(first: Int, role: Role): [User!]\n}\n\ntype Mutation {\n  deletePost(id: ID!): Boolean!\n}\n\nscalar JSON"
+		if len(sdl) < len(expected) || sdl[:len(expected)] != expected {
+			t.Error("unexpected SDL " + sdl)
+		}
+		if _, err := (Schema{Query: map[string]RootField{"user": {Type: User{}, Arguments: map[string]string{"filter": "UserFilter"}}}}).SDL(); err == nil {
+			t.Error("expected `SDL` to return error for unknown argument type")
+		}
+	})
+	t.Run("parses fields that are exposed", func(t *testing.T) {
+		schema := Schema{Query: map[string]RootField{"user": {Type: User{}}}}
+		if _, err := schema.Parse(`{ user { name, role } }`, "", nil); err != nil {
+			t.Error("did not expect `Parse` to return error: " + err.Error())
+		}
+		if _, err := schema.Parse(`{ user { internal } }`, "", nil); err == nil {
+			t.Error("expected `Parse` to return error for field that is not exposed")
+		}
+	})
+}
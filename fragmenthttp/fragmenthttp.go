@@ -0,0 +1,136 @@
+// Package fragmenthttp provides `net/http` glue for fragment-driven responses: middleware that parses the fragment of a request
+// from a query parameter or header, and a response helper that marshals the response value using the fragment.
+package fragmenthttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+// Options configures where the middleware reads the fragment of a request from
+type Options struct {
+	// QueryParam is the query parameter containing the fragment expression. Defaults to "fields".
+	QueryParam string
+	// Header is the header containing the fragment expression, which is used if the query parameter is not specified. Defaults to "X-Fields".
+	Header string
+}
+
+// ErrorResponse is the body of responses written by `WriteError`, e.g. `{ "error": { "code": "invalid_fragment", "message": "..." } }`
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes an error of an error response
+type ErrorBody struct {
+	// Code is a machine-readable code of the error, i.e. `CodeInvalidFragment`, `CodeInvalidFragmentFields`, or `CodeInternal`
+	Code string `json:"code"`
+	// Message is a human-readable description of the error
+	Message string `json:"message"`
+	// Path is the path of fields at which the error occurred, if any
+	Path []string `json:"path,omitempty"`
+}
+
+const (
+	// CodeInvalidFragment is the code of errors when the fragment expression of a request cannot be parsed
+	CodeInvalidFragment = "invalid_fragment"
+	// CodeInvalidFragmentFields is the code of errors when the fragment of a request does not match the type of the response
+	CodeInvalidFragmentFields = "invalid_fragment_fields"
+	// CodeInternal is the code of errors when the response cannot be marshaled
+	CodeInternal = "internal"
+)
+
+// Middleware returns middleware that reads the fragment expression of requests from the configured query parameter or header,
+// parses it using `fragment.ParseUnstructured`, and stores it in the request context, where it can be retrieved using `FromRequest`.
+// If the fragment expression cannot be parsed, a 400 response is written using `WriteError` and the next handler is not called.
+func Middleware(options ...Options) func(http.Handler) http.Handler {
+	option := optionsOf(options)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expr, ok := fragmentExpr(r, option)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			unstructured, err := fragment.ParseUnstructured(expr)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, CodeInvalidFragment, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, unstructured)))
+		})
+	}
+}
+
+// FromRequest returns the fragment stored in the request context by `Middleware`, and false if no fragment was specified for the request.
+// If no fragment was specified, an undefined fragment is returned.
+func FromRequest(r *http.Request) (fragment.Unstructured, bool) {
+	unstructured, ok := r.Context().Value(contextKey{}).(fragment.Unstructured)
+	return unstructured, ok
+}
+
+// WriteJSON writes the value as a JSON response with status 200, where the value is picked using the fragment of the request,
+// as parsed by `fragment.Parse` against the type of the value. If the fragment does not match the type of the value, a 400 response is written
+// using `WriteError`. A policy can be passed to determine which values are presented as null, otherwise `fragment.DefaultPolicy` is used.
+// The returned error is the error that caused an error response to be written, if any.
+func WriteJSON(w http.ResponseWriter, r *http.Request, value interface{}, policy ...fragment.Policy) error {
+	unstructured, _ := FromRequest(r)
+	structFragment, err := fragment.Parse(value, unstructured)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, CodeInvalidFragmentFields, err)
+		return err
+	}
+	body, err := fragment.MarshalJSON(structFragment, value, policy...)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, CodeInternal, err)
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteError writes an `ErrorResponse` with the status code, the code, and the message of the error.
+// If the error is a `fragment.Error`, its path is included in the response.
+func WriteError(w http.ResponseWriter, statusCode int, code string, err error) {
+	body := ErrorBody{Code: code, Message: err.Error()}
+	var fragmentErr fragment.Error
+	if errors.As(err, &fragmentErr) {
+		body.Message = fragmentErr.Err.Error()
+		body.Path = fragmentErr.Path
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: body})
+}
+
+type contextKey struct{}
+
+func fragmentExpr(r *http.Request, option Options) (string, bool) {
+	if values, ok := r.URL.Query()[option.QueryParam]; ok && len(values) > 0 {
+		return values[0], true
+	}
+	if values := r.Header.Values(option.Header); len(values) > 0 {
+		return values[0], true
+	}
+	return "", false
+}
+
+// optionsOf returns the first passed options with defaults for unspecified options
+func optionsOf(options []Options) Options {
+	option := Options{}
+	if len(options) > 0 {
+		option = options[0]
+	}
+	if option.QueryParam == "" {
+		option.QueryParam = "fields"
+	}
+	if option.Header == "" {
+		option.Header = "X-Fields"
+	}
+	return option
+}
@@ -0,0 +1,67 @@
+package fragmenthttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	type Profile struct {
+		Bio   string `json:"bio"`
+		Email string `json:"email"`
+	}
+	type User struct {
+		Name    string   `json:"name"`
+		Age     int      `json:"age"`
+		Profile *Profile `json:"profile"`
+	}
+	handler := Middleware(Options{Header: "X-Fragment"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, r, User{Name: "Ada", Age: 36, Profile: &Profile{Bio: "bio", Email: "ada@example.com"}})
+	}))
+	serve := func(target string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for key, values := range header {
+			r.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	t.Run("picks fields from query parameter", func(t *testing.T) {
+		w := serve("/?fields=name,profile%7Bbio%7D", nil)
+		if w.Code != http.StatusOK {
+			t.Error("expected status 200")
+		}
+		if body := w.Body.String(); body != `{"name":"Ada","profile":{"bio":"bio"}}` {
+			t.Error("unexpected body " + body)
+		}
+	})
+	t.Run("picks fields from header", func(t *testing.T) {
+		w := serve("/", http.Header{"X-Fragment": {"age"}})
+		if body := w.Body.String(); body != `{"age":36}` {
+			t.Error("unexpected body " + body)
+		}
+	})
+	t.Run("picks all fields without fragment", func(t *testing.T) {
+		w := serve("/", nil)
+		if body := w.Body.String(); body != `{"age":36,"name":"Ada","profile":{"bio":"bio","email":"ada@example.com"}}` {
+			t.Error("unexpected body " + body)
+		}
+	})
+	t.Run("writes structured errors", func(t *testing.T) {
+		for target, code := range map[string]string{"/?fields=name%7B": CodeInvalidFragment, "/?fields=+": CodeInvalidFragment, "/?fields=name%20%7B%20%7D": CodeInvalidFragment, "/?fields=height": CodeInvalidFragmentFields} {
+			w := serve(target, nil)
+			if w.Code != http.StatusBadRequest {
+				t.Error("expected status 400 for " + target)
+			}
+			response := ErrorResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("did not expect error response to be invalid JSON: " + err.Error())
+			} else if response.Error.Code != code || response.Error.Message == "" {
+				t.Error("unexpected error response " + w.Body.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package fragmentresolve
+
+import (
+	"strings"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+// Error is an error returned by the resolver of the field at the path
+type Error struct {
+	// Path is the path of the field from the root value, e.g. `posts[0].author`
+	Path fragment.StructPath
+	Err  error
+}
+
+func (e Error) Error() string {
+	return e.Path.JSONExpr() + ": " + e.Err.Error()
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors is the errors returned by resolvers during resolution
+type Errors []Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,137 @@
+// Package fragmentresolve resolves the fields selected by a struct fragment using resolvers registered per struct field,
+// so that only the fields that are queried are resolved.
+package fragmentresolve
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	fragment "github.com/ludvigalden/go-fragment"
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// Resolver resolves the value of a field of a struct. The parent is a copy of the struct as it was before the fields at its level were resolved,
+// and the field includes the fragment of the field, which is also carried by the context, see `fragment.FromContext`.
+// The returned value is assigned to the field, and must be assignable or convertible to the type of the field, or to its element type if it is a pointer.
+type Resolver func(ctx context.Context, parent interface{}, field fragment.StructField) (interface{}, error)
+
+// BatchResolver resolves a field of many structs at once, e.g. the `author` of every post at a level of the resolution, which avoids resolving
+// the field once per struct. It receives the distinct keys of the structs, as returned by the `KeyFunc` of the field, and returns the resolved values
+// by key, which are assigned to the field of every struct with that key. Structs whose keys are missing from the returned map are left as they are.
+type BatchResolver func(ctx context.Context, keys []interface{}, field fragment.StructField) (map[interface{}]interface{}, error)
+
+// KeyFunc returns the key of a struct by which the field of the struct is resolved in batch, e.g. the author ID of a post. The parent is a copy
+// of the struct. The key must be comparable, and if it is nil, the field of the struct is not resolved.
+type KeyFunc func(parent interface{}) interface{}
+
+// Options configures a registry
+type Options struct {
+	// Concurrency is the maximum amount of resolvers, including batch resolvers, that are run at once. If zero, the amount is not limited.
+	Concurrency int
+}
+
+// Registry is a registry of resolvers keyed by struct field, which are called by `Resolve` for the fields selected by a fragment.
+type Registry struct {
+	mutex          sync.RWMutex
+	resolvers      map[resolverKey]Resolver
+	batchResolvers map[resolverKey]batchResolver
+	options        Options
+}
+
+type batchResolver struct {
+	key      KeyFunc
+	resolver BatchResolver
+}
+
+type resolverKey struct {
+	structType reflect.Type
+	fieldIndex int
+}
+
+// NewRegistry returns a new registry without resolvers
+func NewRegistry(options ...Options) *Registry {
+	registry := &Registry{resolvers: map[resolverKey]Resolver{}, batchResolvers: map[resolverKey]batchResolver{}}
+	if len(options) > 0 {
+		registry.options = options[0]
+	}
+	return registry
+}
+
+// Register registers the resolver of the field of the specified struct type, where the field name can be the struct field name or the JSON name.
+// A previously registered resolver, or batch resolver, of the field is replaced.
+func (r *Registry) Register(t interface{}, fieldName string, resolver Resolver) error {
+	structField, err := structFieldOf(t, fieldName)
+	if err != nil {
+		return err
+	}
+	return r.RegisterField(t, structField, resolver)
+}
+
+// RegisterField registers the resolver of a struct field of the specified struct type. A previously registered resolver, or batch resolver, of the field is replaced.
+func (r *Registry) RegisterField(t interface{}, structField typemeta.StructField, resolver Resolver) error {
+	structTypeMeta := typemeta.StructOf(typemeta.Get(t))
+	if structTypeMeta == nil {
+		return errors.New("cannot register resolver for non-struct type " + typemeta.Get(t).String())
+	} else if resolver == nil {
+		return errors.New("cannot register nil resolver for field \"" + structField.String() + "\"")
+	}
+	key := resolverKey{structType: structTypeMeta.Type(), fieldIndex: structField.Index}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.batchResolvers, key)
+	r.resolvers[key] = resolver
+	return nil
+}
+
+// RegisterBatch registers the batch resolver of the field of the specified struct type, where the field name can be the struct field name or the JSON name.
+// When the field is selected, the keys of all structs at a level of the resolution are gathered using the key function, and the batch resolver is called once
+// for the level. A previously registered resolver, or batch resolver, of the field is replaced.
+func (r *Registry) RegisterBatch(t interface{}, fieldName string, keyFunc KeyFunc, resolver BatchResolver) error {
+	structField, err := structFieldOf(t, fieldName)
+	if err != nil {
+		return err
+	} else if keyFunc == nil || resolver == nil {
+		return errors.New("cannot register nil batch resolver for field \"" + structField.String() + "\"")
+	}
+	key := resolverKey{structType: typemeta.StructOf(typemeta.Get(t)).Type(), fieldIndex: structField.Index}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.resolvers, key)
+	r.batchResolvers[key] = batchResolver{key: keyFunc, resolver: resolver}
+	return nil
+}
+
+// Resolver returns the resolver of a struct field of the specified struct type, and nil if no resolver is registered for it
+func (r *Registry) Resolver(t interface{}, structField typemeta.StructField) Resolver {
+	structTypeMeta := typemeta.StructOf(typemeta.Get(t))
+	if structTypeMeta == nil {
+		return nil
+	}
+	resolver, _ := r.resolverOf(structTypeMeta.Type(), structField.Index)
+	return resolver
+}
+
+func (r *Registry) resolverOf(structType reflect.Type, fieldIndex int) (Resolver, *batchResolver) {
+	key := resolverKey{structType: structType, fieldIndex: fieldIndex}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if batchResolver, ok := r.batchResolvers[key]; ok {
+		return nil, &batchResolver
+	}
+	return r.resolvers[key], nil
+}
+
+// structFieldOf returns the field with the specified name of the specified struct type
+func structFieldOf(t interface{}, fieldName string) (typemeta.StructField, error) {
+	structTypeMeta := typemeta.StructOf(typemeta.Get(t))
+	if structTypeMeta == nil {
+		return typemeta.StructField{}, errors.New("cannot register resolver for non-struct type " + typemeta.Get(t).String())
+	}
+	structField := structTypeMeta.FieldByName(fieldName)
+	if structField == nil {
+		return typemeta.StructField{}, errors.New("field with name \"" + fieldName + "\" does not exist in " + structTypeMeta.String())
+	}
+	return *structField, nil
+}
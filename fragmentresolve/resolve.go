@@ -0,0 +1,290 @@
+package fragmentresolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	fragment "github.com/ludvigalden/go-fragment"
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// Resolve resolves the fields selected by the fragment of the root, which must be a non-nil pointer to a struct, or to a slice, array, or map
+// of structs, by calling the registered resolvers of the selected fields and assigning the resolved values. The values are walked level by level,
+// where the resolvers of all structs at a level, e.g. the `author` of every post, are run concurrently, limited by the concurrency of the registry,
+// after which the selected fields of the resolved values are resolved in turn. Maps are only walked if their elements are pointers.
+// Fields with batch resolvers are resolved once per level for all structs, see `RegisterBatch`. Errors of resolvers do not stop the resolution
+// of other fields, and are returned as `Errors` with the path of each field.
+func (r *Registry) Resolve(ctx context.Context, f fragment.Struct, root interface{}) error {
+	rootValue := reflect.ValueOf(root)
+	if rootValue.Kind() != reflect.Ptr || rootValue.IsNil() {
+		return errors.New("expected root to be a non-nil pointer, but received " + typemeta.Get(root).String())
+	}
+	rootFragment, err := fragment.Parse(root, f)
+	if err != nil {
+		return err
+	} else if !rootFragment.IsValid() {
+		return errors.New("cannot resolve non-struct type " + typemeta.Get(root).String())
+	}
+	resolution := &resolution{
+		registry:     r,
+		rootTypeMeta: typemeta.Get(rootValue.Elem().Type()),
+		visited:      map[visitedKey]bool{},
+	}
+	if r.options.Concurrency > 0 {
+		resolution.semaphore = make(chan struct{}, r.options.Concurrency)
+	}
+	level := resolution.collect(rootValue.Elem(), rootFragment, nil)
+	for len(level) > 0 {
+		failed := resolution.resolveLevel(ctx, level)
+		nextLevel := []instance{}
+		for i, levelInstance := range level {
+			levelInstance.fragment.IterateFields(func(field fragment.StructField) {
+				if failed[i][field.Index] || !field.Fragment.IsValid() {
+					return
+				}
+				nextLevel = append(nextLevel, resolution.collect(levelInstance.value.Field(field.Index), field.Fragment, appendPath(levelInstance.path, field.Index))...)
+			})
+		}
+		level = nextLevel
+	}
+	if len(resolution.errs) > 0 {
+		return resolution.errs
+	}
+	return nil
+}
+
+// resolution is the state of a call to `Resolve`
+type resolution struct {
+	registry     *Registry
+	rootTypeMeta typemeta.TypeMeta
+	semaphore    chan struct{}
+	visited      map[visitedKey]bool
+	mutex        sync.Mutex
+	errs         Errors
+}
+
+// instance is an addressable struct value at a level of the resolution, and the fragment and path of it
+type instance struct {
+	value    reflect.Value
+	fragment fragment.Struct
+	path     []interface{}
+}
+
+type visitedKey struct {
+	pointer    uintptr
+	structType reflect.Type
+}
+
+// collect returns the struct values of a value, i.e. the value itself if it is a struct, or the struct elements of pointers, slices, arrays, and maps.
+// Each struct value is only collected once, so that cyclic values are not walked infinitely.
+func (res *resolution) collect(value reflect.Value, f fragment.Struct, path []interface{}) []instance {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return res.collect(value.Elem(), f, path)
+	case reflect.Struct:
+		if !value.CanAddr() || value.Type() != f.TypeMeta().Type() {
+			return nil
+		}
+		key := visitedKey{pointer: value.Addr().Pointer(), structType: value.Type()}
+		if res.visited[key] {
+			return nil
+		}
+		res.visited[key] = true
+		return []instance{{value: value, fragment: f, path: path}}
+	case reflect.Slice, reflect.Array:
+		instances := []instance{}
+		for i := 0; i < value.Len(); i++ {
+			instances = append(instances, res.collect(value.Index(i), f, appendPath(path, fragment.IndexSelector(i)))...)
+		}
+		return instances
+	case reflect.Map:
+		instances := []instance{}
+		iter := value.MapRange()
+		for iter.Next() {
+			if iter.Value().Kind() == reflect.Ptr {
+				instances = append(instances, res.collect(iter.Value(), f, appendPath(path, fragment.KeySelector(fmt.Sprint(iter.Key().Interface()))))...)
+			}
+		}
+		return instances
+	}
+	return nil
+}
+
+// resolveLevel runs the resolvers of the selected fields of the instances concurrently, and returns the indices of the fields that failed per instance.
+// The fields with batch resolvers are grouped by struct type and field, so that each batch resolver is called once for the level with the keys of all instances.
+func (res *resolution) resolveLevel(ctx context.Context, level []instance) []map[int]bool {
+	failed := make([]map[int]bool, len(level))
+	batches := map[resolverKey]*levelBatch{}
+	batchKeys := []resolverKey{}
+	waitGroup := sync.WaitGroup{}
+	for i, levelInstance := range level {
+		failed[i] = map[int]bool{}
+		// the parent is copied before any of its fields are resolved, since resolvers run concurrently with the assignment of sibling fields
+		var parent interface{}
+		levelInstance.fragment.IterateFields(func(field fragment.StructField) {
+			resolver, batchResolver := res.registry.resolverOf(levelInstance.value.Type(), field.Index)
+			if resolver == nil && batchResolver == nil {
+				return
+			}
+			if parent == nil {
+				parent = levelInstance.value.Interface()
+			}
+			if batchResolver != nil {
+				key := resolverKey{structType: levelInstance.value.Type(), fieldIndex: field.Index}
+				batch, ok := batches[key]
+				if !ok {
+					batch = &levelBatch{resolver: *batchResolver, field: field}
+					batches[key] = batch
+					batchKeys = append(batchKeys, key)
+				}
+				batch.members = append(batch.members, batchMember{index: i, instance: levelInstance, key: batchResolver.key(parent)})
+				return
+			}
+			waitGroup.Add(1)
+			go func(i int, instance instance, field fragment.StructField, parent interface{}) {
+				defer waitGroup.Done()
+				if err := res.resolveField(ctx, instance, field, parent, resolver); err != nil {
+					res.fail(failed, i, instance, field, err)
+				}
+			}(i, levelInstance, field, parent)
+		})
+	}
+	for _, key := range batchKeys {
+		waitGroup.Add(1)
+		go func(batch *levelBatch) {
+			defer waitGroup.Done()
+			res.resolveBatch(ctx, batch, failed)
+		}(batches[key])
+	}
+	waitGroup.Wait()
+	return failed
+}
+
+// levelBatch is the instances of a level whose field is resolved by a batch resolver, and the keys of them
+type levelBatch struct {
+	resolver batchResolver
+	field    fragment.StructField
+	members  []batchMember
+}
+
+type batchMember struct {
+	index    int
+	instance instance
+	key      interface{}
+}
+
+func (res *resolution) resolveField(ctx context.Context, instance instance, field fragment.StructField, parent interface{}, resolver Resolver) error {
+	release, err := res.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	resolved, err := resolver(fieldContext(ctx, field), parent, field)
+	if err != nil {
+		return err
+	}
+	return assign(instance.value.Field(field.Index), resolved)
+}
+
+// resolveBatch calls the batch resolver with the distinct keys of the members of the batch, and scatters the resolved values to the members by key
+func (res *resolution) resolveBatch(ctx context.Context, batch *levelBatch, failed []map[int]bool) {
+	keys := []interface{}{}
+	seen := map[interface{}]bool{}
+	for _, member := range batch.members {
+		if member.key != nil && !seen[member.key] {
+			seen[member.key] = true
+			keys = append(keys, member.key)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	release, err := res.acquire(ctx)
+	if err != nil {
+		for _, member := range batch.members {
+			res.fail(failed, member.index, member.instance, batch.field, err)
+		}
+		return
+	}
+	resolved, err := batch.resolver.resolver(fieldContext(ctx, batch.field), keys, batch.field)
+	release()
+	for _, member := range batch.members {
+		if member.key == nil {
+			continue
+		} else if err != nil {
+			res.fail(failed, member.index, member.instance, batch.field, err)
+		} else if value, ok := resolved[member.key]; ok {
+			if err := assign(member.instance.value.Field(batch.field.Index), value); err != nil {
+				res.fail(failed, member.index, member.instance, batch.field, err)
+			}
+		}
+	}
+}
+
+// acquire waits for a resolver to be allowed to run according to the concurrency of the registry, and returns a function releasing it
+func (res *resolution) acquire(ctx context.Context) (func(), error) {
+	if res.semaphore == nil {
+		return func() {}, nil
+	}
+	select {
+	case res.semaphore <- struct{}{}:
+		return func() { <-res.semaphore }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fail records the error of the field of the instance with the specified index in the level
+func (res *resolution) fail(failed []map[int]bool, i int, instance instance, field fragment.StructField, err error) {
+	res.mutex.Lock()
+	defer res.mutex.Unlock()
+	failed[i][field.Index] = true
+	path, pathErr := res.pathOf(appendPath(instance.path, field.Index))
+	if pathErr != nil {
+		err = errors.New(err.Error() + " (invalid path of field \"" + field.Name + "\": " + pathErr.Error() + ")")
+	}
+	res.errs = append(res.errs, Error{Path: path, Err: err})
+}
+
+// fieldContext returns the context passed to the resolvers of a field, which carries the fragment of the field if it is a struct
+func fieldContext(ctx context.Context, field fragment.StructField) context.Context {
+	if field.Fragment.IsValid() {
+		return fragment.WithFragment(ctx, field.Fragment)
+	}
+	return ctx
+}
+
+func (res *resolution) pathOf(path []interface{}) (fragment.StructPath, error) {
+	return fragment.ParseStructPath(res.rootTypeMeta, path...)
+}
+
+// assign assigns a resolved value to a field, converting it to the type of the field or allocating a pointer if necessary
+func assign(fieldValue reflect.Value, resolved interface{}) error {
+	resolvedValue := reflect.ValueOf(resolved)
+	fieldType := fieldValue.Type()
+	switch {
+	case !resolvedValue.IsValid():
+		fieldValue.Set(reflect.Zero(fieldType))
+	case resolvedValue.Type().AssignableTo(fieldType):
+		fieldValue.Set(resolvedValue)
+	case resolvedValue.Type().ConvertibleTo(fieldType):
+		fieldValue.Set(resolvedValue.Convert(fieldType))
+	case fieldType.Kind() == reflect.Ptr && resolvedValue.Type().ConvertibleTo(fieldType.Elem()):
+		ptr := reflect.New(fieldType.Elem())
+		ptr.Elem().Set(resolvedValue.Convert(fieldType.Elem()))
+		fieldValue.Set(ptr)
+	default:
+		return errors.New("cannot assign resolved value of type " + resolvedValue.Type().String() + " to field of type " + fieldType.String())
+	}
+	return nil
+}
+
+func appendPath(path []interface{}, elem interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(path)+1), path...), elem)
+}
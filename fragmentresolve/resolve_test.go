@@ -0,0 +1,192 @@
+package fragmentresolve
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+func TestResolve(t *testing.T) {
+	type User struct {
+		ID        int    `json:"id"`
+		Name      string `json:"name"`
+		Followers int    `json:"followers"`
+	}
+	type Post struct {
+		ID       int      `json:"id"`
+		AuthorID int      `json:"authorId"`
+		Author   *User    `json:"author"`
+		Likes    int      `json:"likes"`
+		Tags     []string `json:"tags"`
+	}
+	users := map[int]User{1: {ID: 1, Name: "Ada"}, 2: {ID: 2, Name: "Charles"}}
+	newRegistry := func(concurrency int, calls *int32, running *int32, maxRunning *int32) *Registry {
+		registry := NewRegistry(Options{Concurrency: concurrency})
+		track := func() func() {
+			atomic.AddInt32(calls, 1)
+			current := atomic.AddInt32(running, 1)
+			for {
+				max := atomic.LoadInt32(maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return func() { atomic.AddInt32(running, -1) }
+		}
+		registry.Register(Post{}, "author", func(ctx context.Context, parent interface{}, field fragment.StructField) (interface{}, error) {
+			defer track()()
+			if f, ok := fragment.FromContext(ctx); !ok || f.TypeMeta() != field.Fragment.TypeMeta() {
+				return nil, errors.New("expected context to carry the fragment of the field")
+			}
+			user := users[parent.(Post).AuthorID]
+			return &user, nil
+		})
+		registry.Register(Post{}, "likes", func(ctx context.Context, parent interface{}, field fragment.StructField) (interface{}, error) {
+			defer track()()
+			if parent.(Post).ID == 3 {
+				return nil, errors.New("likes are unavailable")
+			}
+			return parent.(Post).ID * 10, nil
+		})
+		registry.Register(Post{}, "tags", func(ctx context.Context, parent interface{}, field fragment.StructField) (interface{}, error) {
+			defer track()()
+			return []string{"go"}, nil
+		})
+		registry.Register(User{}, "followers", func(ctx context.Context, parent interface{}, field fragment.StructField) (interface{}, error) {
+			defer track()()
+			return int64(parent.(User).ID * 100), nil
+		})
+		return registry
+	}
+	t.Run("resolves selected fields", func(t *testing.T) {
+		var calls, running, maxRunning int32
+		registry := newRegistry(0, &calls, &running, &maxRunning)
+		f, _ := fragment.ParseStruct(Post{}, "id, author { name, followers }, likes")
+		posts := []Post{{ID: 1, AuthorID: 1}, {ID: 2, AuthorID: 2}}
+		if err := registry.Resolve(context.Background(), f, &posts); err != nil {
+			t.Error("did not expect `Resolve` to return error: " + err.Error())
+			return
+		}
+		if posts[0].Author == nil || posts[0].Author.Name != "Ada" || posts[1].Author.Name != "Charles" || posts[1].Likes != 20 {
+			t.Error("expected selected fields to be resolved")
+		}
+		if posts[0].Author.Followers != 100 || posts[1].Author.Followers != 200 {
+			t.Error("expected nested selected fields to be resolved")
+		}
+		if posts[0].Tags != nil || calls != 6 {
+			t.Error("expected only selected fields to be resolved")
+		}
+		if maxRunning < 2 {
+			t.Error("expected resolvers to run concurrently")
+		}
+	})
+	t.Run("limits concurrency", func(t *testing.T) {
+		var calls, running, maxRunning int32
+		registry := newRegistry(1, &calls, &running, &maxRunning)
+		post := Post{ID: 1, AuthorID: 1}
+		if err := registry.Resolve(context.Background(), fragment.NewStruct(Post{}), &post); err != nil {
+			t.Error("did not expect `Resolve` to return error: " + err.Error())
+			return
+		}
+		if maxRunning != 1 {
+			t.Error("expected at most one resolver to run at once")
+		}
+		if post.Author == nil || post.Author.Followers != 100 || len(post.Tags) != 1 {
+			t.Error("expected all fields to be resolved for undefined fragment")
+		}
+	})
+	t.Run("collects errors with paths", func(t *testing.T) {
+		var calls, running, maxRunning int32
+		registry := newRegistry(0, &calls, &running, &maxRunning)
+		f, _ := fragment.ParseStruct(Post{}, "likes, author { name }")
+		posts := []*Post{{ID: 1, AuthorID: 1}, {ID: 3, AuthorID: 2}}
+		err := registry.Resolve(context.Background(), f, &posts)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 1 {
+			t.Error("expected `Resolve` to return one error")
+			return
+		}
+		if expr := errs[0].Path.JSONExpr(); expr != "[1].likes" {
+			t.Error("unexpected error path " + expr)
+		}
+		if posts[1].Author == nil || posts[0].Likes != 10 {
+			t.Error("expected other fields to be resolved despite error")
+		}
+	})
+	t.Run("resolves fields in batches per level", func(t *testing.T) {
+		var authorCalls, followerCalls int32
+		var authorKeys []interface{}
+		registry := NewRegistry()
+		registry.RegisterBatch(Post{}, "author", func(parent interface{}) interface{} {
+			if parent.(Post).AuthorID == 0 {
+				return nil
+			}
+			return parent.(Post).AuthorID
+		}, func(ctx context.Context, keys []interface{}, field fragment.StructField) (map[interface{}]interface{}, error) {
+			atomic.AddInt32(&authorCalls, 1)
+			authorKeys = keys
+			resolved := map[interface{}]interface{}{}
+			for _, key := range keys {
+				if user, ok := users[key.(int)]; ok {
+					resolved[key] = &user
+				}
+			}
+			return resolved, nil
+		})
+		registry.RegisterBatch(User{}, "followers", func(parent interface{}) interface{} {
+			return parent.(User).ID
+		}, func(ctx context.Context, keys []interface{}, field fragment.StructField) (map[interface{}]interface{}, error) {
+			atomic.AddInt32(&followerCalls, 1)
+			if len(keys) != 2 {
+				return nil, errors.New("expected keys of all authors")
+			}
+			return map[interface{}]interface{}{1: 100, 2: 200}, nil
+		})
+		f, _ := fragment.ParseStruct(Post{}, "author { name, followers }")
+		posts := []Post{{ID: 1, AuthorID: 1}, {ID: 2, AuthorID: 2}, {ID: 3, AuthorID: 1}, {ID: 4}, {ID: 5, AuthorID: 3}}
+		if err := registry.Resolve(context.Background(), f, &posts); err != nil {
+			t.Error("did not expect `Resolve` to return error: " + err.Error())
+			return
+		}
+		if authorCalls != 1 || followerCalls != 1 {
+			t.Error("expected each batch resolver to be called once per level")
+		}
+		if len(authorKeys) != 3 {
+			t.Error("expected distinct non-nil keys to be passed to batch resolver")
+		}
+		if posts[0].Author == nil || posts[0].Author.Name != "Ada" || posts[1].Author.Name != "Charles" || posts[2].Author.Name != "Ada" {
+			t.Error("expected resolved values to be scattered to structs by key")
+			return
+		}
+		if posts[3].Author != nil || posts[4].Author != nil {
+			t.Error("expected structs with nil or missing keys to be left as they are")
+		}
+		if posts[0].Author.Followers != 100 || posts[1].Author.Followers != 200 || posts[2].Author.Followers != 100 {
+			t.Error("expected nested fields to be resolved in batches")
+		}
+	})
+	t.Run("collects errors of batch resolvers for each struct", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.RegisterBatch(Post{}, "likes", func(parent interface{}) interface{} {
+			return parent.(Post).ID
+		}, func(ctx context.Context, keys []interface{}, field fragment.StructField) (map[interface{}]interface{}, error) {
+			return nil, errors.New("likes are unavailable")
+		})
+		f, _ := fragment.ParseStruct(Post{}, "likes")
+		posts := []Post{{ID: 1}, {ID: 2}}
+		err := registry.Resolve(context.Background(), f, &posts)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 2 {
+			t.Error("expected `Resolve` to return one error per struct")
+			return
+		}
+		if expr := errs[0].Path.JSONExpr() + ", " + errs[1].Path.JSONExpr(); expr != "[0].likes, [1].likes" {
+			t.Error("unexpected error paths " + expr)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+// Package fragmentsql selects and scans only the columns of the fields included in a struct fragment, using the `db` tags of struct fields,
+// e.g. `db:"display_name"`, so that queries do not fetch columns that are not queried. Relations included in fragments can be loaded using a `Plan`,
+// which joins or batches the queries of related tables as specified by the `fragment` tags of struct fields.
+package fragmentsql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect determines how identifiers are quoted and how parameters are referenced in SQL statements
+type Dialect interface {
+	// QuoteIdentifier returns the quoted identifier, e.g. `"display_name"` or "`display_name`"
+	QuoteIdentifier(identifier string) string
+	// Placeholder returns the placeholder of the parameter at the one-based index, e.g. `$1` or `?`
+	Placeholder(index int) string
+}
+
+// Postgres is the dialect of PostgreSQL, which quotes identifiers with double quotes and uses numbered placeholders
+var Postgres Dialect = quoteDialect{quote: `"`, numberedPlaceholders: true}
+
+// MySQL is the dialect of MySQL, which quotes identifiers with backticks
+var MySQL Dialect = quoteDialect{quote: "`"}
+
+// SQLite is the dialect of SQLite, which quotes identifiers with double quotes
+var SQLite Dialect = quoteDialect{quote: `"`}
+
+type quoteDialect struct {
+	quote                string
+	numberedPlaceholders bool
+}
+
+// QuoteIdentifier quotes the identifier, escaping quotes within it by doubling them. Qualified identifiers such as `posts.title` are quoted per part.
+func (d quoteDialect) QuoteIdentifier(identifier string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = d.quote + strings.ReplaceAll(part, d.quote, d.quote+d.quote) + d.quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// Placeholder returns `$1`, `$2`, etc. if the dialect uses numbered placeholders, and otherwise `?`
+func (d quoteDialect) Placeholder(index int) string {
+	if d.numberedPlaceholders {
+		return "$" + strconv.Itoa(index)
+	}
+	return "?"
+}
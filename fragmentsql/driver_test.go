@@ -0,0 +1,115 @@
+package fragmentsql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// fakeQueryHandler returns the columns and rows of a query
+type fakeQueryHandler func(query string, args []driver.Value) ([]string, [][]driver.Value, error)
+
+// fakeDriver is a `database/sql` driver whose queries are answered by the handler registered for the data source name
+type fakeDriver struct{}
+
+var fakeHandlers = struct {
+	sync.Mutex
+	handlers map[string]fakeQueryHandler
+	queries  map[string][]string
+}{handlers: map[string]fakeQueryHandler{}, queries: map[string][]string{}}
+
+func init() {
+	sql.Register("fragmentsqlfake", fakeDriver{})
+}
+
+// openFakeDB opens a database whose queries are answered by the handler, and returns a function returning the executed queries
+func openFakeDB(handler fakeQueryHandler) (*sql.DB, func() []string) {
+	fakeHandlers.Lock()
+	dsn := strconv.Itoa(len(fakeHandlers.handlers))
+	fakeHandlers.handlers[dsn] = handler
+	fakeHandlers.Unlock()
+	db, err := sql.Open("fragmentsqlfake", dsn)
+	if err != nil {
+		panic(err)
+	}
+	return db, func() []string {
+		fakeHandlers.Lock()
+		defer fakeHandlers.Unlock()
+		return append([]string{}, fakeHandlers.queries[dsn]...)
+	}
+}
+
+func (d fakeDriver) Open(dsn string) (driver.Conn, error) {
+	return fakeConn{dsn: dsn}, nil
+}
+
+type fakeConn struct {
+	dsn string
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{dsn: c.dsn, query: query}, nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	dsn   string
+	query string
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	fakeHandlers.Lock()
+	handler := fakeHandlers.handlers[s.dsn]
+	fakeHandlers.queries[s.dsn] = append(fakeHandlers.queries[s.dsn], s.query)
+	fakeHandlers.Unlock()
+	columns, rows, err := handler(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	index   int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.index >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.index])
+	r.index++
+	return nil
+}
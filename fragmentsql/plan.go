@@ -0,0 +1,363 @@
+package fragmentsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+
+	fragment "github.com/ludvigalden/go-fragment"
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// Queryer runs queries, and is implemented by `*sql.DB`, `*sql.Conn`, and `*sql.Tx`
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Plan is the plan of loading the fields included in a struct fragment, including the relations of the struct as specified by the `fragment` tags
+// of struct fields, e.g. `fragment:"rel=belongsto,table=users,fk=author_id"`, see the `BelongsTo`, `HasOne`, and `HasMany` relations.
+// To-one relations are by default loaded using a `LEFT JOIN`, and to-many relations, or relations with the option `load=batch`,
+// are loaded using a second query for all structs of a query, keyed by the foreign key, so that relations do not result in a query per struct.
+type Plan struct {
+	dialect Dialect
+	root    *planNode
+}
+
+// planNode is the plan of loading a struct from a table, or from a joined table
+type planNode struct {
+	typeMeta *typemeta.Struct
+	table    string
+	alias    string
+	// fields is the fields with columns to select
+	fields []typemeta.StructField
+	// keyColumns is the columns to select in addition to the columns of the fields, by which relations are keyed
+	keyColumns []string
+	joins      []planJoin
+	batches    []planBatch
+}
+
+type planJoin struct {
+	field    typemeta.StructField
+	relation relation
+	node     *planNode
+}
+
+type planBatch struct {
+	field    typemeta.StructField
+	relation relation
+	plan     *Plan
+}
+
+// planInstance is a struct loaded by a node, and the values of the key columns of the node
+type planInstance struct {
+	value reflect.Value
+	keys  map[string]interface{}
+}
+
+// NewPlan returns the plan of loading the fields included in the fragment from the table. Fields with a `db` tag are selected as columns,
+// and fields with a relation in their `fragment` tag are loaded from the related table using the fragment of the field.
+// Relations are only loaded when they are included in a defined fragment, so an undefined fragment only selects the columns of the struct.
+func NewPlan(f fragment.Struct, table string, dialect Dialect) (*Plan, error) {
+	if !f.IsValid() {
+		return nil, errors.New("cannot plan loading of invalid fragment")
+	}
+	root, err := newPlanNode(f, table, table, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{dialect: dialect, root: root}, nil
+}
+
+func newPlanNode(f fragment.Struct, table string, alias string, dialect Dialect) (*planNode, error) {
+	node := &planNode{typeMeta: f.TypeMeta(), table: table, alias: alias}
+	var err error
+	f.IterateFields(func(field fragment.StructField) {
+		if err != nil {
+			return
+		} else if _, ok := columnOf(field.StructField); ok {
+			node.fields = append(node.fields, field.StructField)
+			return
+		} else if f.IsUndefined() {
+			return
+		}
+		r, ok, relationErr := relationOf(field.StructField)
+		if relationErr != nil {
+			err = relationErr
+			return
+		} else if !ok {
+			return
+		}
+		if r.load == LoadJoin {
+			joinNode, joinErr := newPlanNode(field.Fragment, r.table, alias+"_"+strings.ToLower(field.Name), dialect)
+			if joinErr != nil {
+				err = fragment.NewError(joinErr).Register(field.Name)
+				return
+			}
+			joinNode.addKeyColumn(r.childKey())
+			node.joins = append(node.joins, planJoin{field: field.StructField, relation: r, node: joinNode})
+		} else {
+			batchNode, batchErr := newPlanNode(field.Fragment, r.table, r.table, dialect)
+			if batchErr != nil {
+				err = fragment.NewError(batchErr).Register(field.Name)
+				return
+			}
+			batchNode.addKeyColumn(r.childKey())
+			node.addKeyColumn(r.parentKey())
+			node.batches = append(node.batches, planBatch{field: field.StructField, relation: r, plan: &Plan{dialect: dialect, root: batchNode}})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (n *planNode) addKeyColumn(column string) {
+	for _, keyColumn := range n.keyColumns {
+		if keyColumn == column {
+			return
+		}
+	}
+	n.keyColumns = append(n.keyColumns, column)
+}
+
+// SQL returns the query of the plan, e.g. `SELECT "posts"."title", "posts_author"."name", ... FROM "posts" LEFT JOIN "users" AS "posts_author" ON ...`,
+// to which a clause such as `WHERE ...` can be appended. Relations loaded in batches are queried separately.
+func (p *Plan) SQL() string {
+	return "SELECT " + strings.Join(p.root.selectList(p.dialect), ", ") + " FROM " + p.root.fromClause(p.dialect)
+}
+
+func (n *planNode) selectList(dialect Dialect) []string {
+	columns := []string{}
+	for _, structField := range n.fields {
+		column, _ := columnOf(structField)
+		columns = append(columns, dialect.QuoteIdentifier(n.alias+"."+column))
+	}
+	for _, keyColumn := range n.keyColumns {
+		columns = append(columns, dialect.QuoteIdentifier(n.alias+"."+keyColumn))
+	}
+	for _, join := range n.joins {
+		columns = append(columns, join.node.selectList(dialect)...)
+	}
+	return columns
+}
+
+func (n *planNode) fromClause(dialect Dialect) string {
+	from := dialect.QuoteIdentifier(n.table)
+	if n.alias != n.table {
+		from += " AS " + dialect.QuoteIdentifier(n.alias)
+	}
+	return from + n.joinClause(dialect)
+}
+
+func (n *planNode) joinClause(dialect Dialect) string {
+	clause := ""
+	for _, join := range n.joins {
+		clause += " LEFT JOIN " + dialect.QuoteIdentifier(join.node.table) + " AS " + dialect.QuoteIdentifier(join.node.alias) +
+			" ON " + dialect.QuoteIdentifier(join.node.alias+"."+join.relation.childKey()) + " = " + dialect.QuoteIdentifier(n.alias+"."+join.relation.parentKey()) +
+			join.node.joinClause(dialect)
+	}
+	return clause
+}
+
+// Query runs the query of the plan followed by the clause, e.g. `WHERE "posts"."published" = $1`, and the queries of relations loaded in batches,
+// and stitches the results into new elements appended to the destination, which must be a pointer to a slice of structs, or of pointers to structs,
+// of the type of the fragment.
+func (p *Plan) Query(ctx context.Context, queryer Queryer, dest interface{}, clause string, args ...interface{}) error {
+	sliceValue, elemIsPtr, err := sliceDest(dest, p.root.typeMeta)
+	if err != nil {
+		return err
+	}
+	instances, err := p.query(ctx, queryer, clause, args)
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		if elemIsPtr {
+			sliceValue = reflect.Append(sliceValue, instance.value.Addr())
+		} else {
+			sliceValue = reflect.Append(sliceValue, instance.value)
+		}
+	}
+	reflect.ValueOf(dest).Elem().Set(sliceValue)
+	return nil
+}
+
+func (p *Plan) query(ctx context.Context, queryer Queryer, clause string, args []interface{}) ([]planInstance, error) {
+	query := p.SQL()
+	if clause != "" {
+		query += " " + clause
+	}
+	rows, err := queryer.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	roots := []planInstance{}
+	instances := map[*planNode][]planInstance{}
+	for rows.Next() {
+		scan := p.root.newScan()
+		if err := rows.Scan(scan.targets()...); err != nil {
+			return nil, err
+		}
+		roots = append(roots, scan.assign(reflect.New(p.root.typeMeta.Type()).Elem(), instances))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// the rows are closed before querying relations, since a single connection may not run multiple queries at once
+	rows.Close()
+	for _, node := range p.root.nodes() {
+		for _, batch := range node.batches {
+			if err := p.loadBatch(ctx, queryer, batch, instances[node]); err != nil {
+				return nil, fragment.NewError(err).Register(batch.field.Name)
+			}
+		}
+	}
+	return roots, nil
+}
+
+// loadBatch queries the related rows of all parents in one query keyed by the foreign key, and sets them to the field of the parents
+func (p *Plan) loadBatch(ctx context.Context, queryer Queryer, batch planBatch, parents []planInstance) error {
+	parentKey, childKey := batch.relation.parentKey(), batch.relation.childKey()
+	keys := []interface{}{}
+	placeholders := []string{}
+	seenKeys := map[interface{}]bool{}
+	for _, parent := range parents {
+		key := normalizeKey(parent.keys[parentKey])
+		if key == nil || seenKeys[key] {
+			continue
+		}
+		seenKeys[key] = true
+		keys = append(keys, parent.keys[parentKey])
+		placeholders = append(placeholders, p.dialect.Placeholder(len(keys)))
+	}
+	children := []planInstance{}
+	if len(keys) > 0 {
+		clause := "WHERE " + p.dialect.QuoteIdentifier(batch.plan.root.alias+"."+childKey) + " IN (" + strings.Join(placeholders, ", ") + ")"
+		var err error
+		if children, err = batch.plan.query(ctx, queryer, clause, keys); err != nil {
+			return err
+		}
+	}
+	childrenByKey := map[interface{}][]planInstance{}
+	for _, child := range children {
+		key := normalizeKey(child.keys[childKey])
+		childrenByKey[key] = append(childrenByKey[key], child)
+	}
+	for _, parent := range parents {
+		related := childrenByKey[normalizeKey(parent.keys[parentKey])]
+		fieldValue := parent.value.Field(batch.field.Index)
+		if batch.relation.kind == HasMany {
+			sliceValue := reflect.MakeSlice(fieldValue.Type(), 0, len(related))
+			for _, child := range related {
+				sliceValue = reflect.Append(sliceValue, relatedValue(fieldValue.Type().Elem(), child.value))
+			}
+			fieldValue.Set(sliceValue)
+		} else if len(related) > 0 {
+			fieldValue.Set(relatedValue(fieldValue.Type(), related[0].value))
+		}
+	}
+	return nil
+}
+
+// nodes returns the node and its joined nodes, recursively
+func (n *planNode) nodes() []*planNode {
+	nodes := []*planNode{n}
+	for _, join := range n.joins {
+		nodes = append(nodes, join.node.nodes()...)
+	}
+	return nodes
+}
+
+// nodeScan holds the values of a row scanned for a node and its joined nodes
+type nodeScan struct {
+	node *planNode
+	// fieldHolders is a pointer to a pointer to a value of each field, so that null values of joined tables can be scanned
+	fieldHolders []reflect.Value
+	keyHolders   []*interface{}
+	joins        []*nodeScan
+}
+
+func (n *planNode) newScan() *nodeScan {
+	scan := &nodeScan{node: n}
+	for _, structField := range n.fields {
+		scan.fieldHolders = append(scan.fieldHolders, reflect.New(reflect.PtrTo(n.typeMeta.Type().Field(structField.Index).Type)))
+	}
+	for range n.keyColumns {
+		scan.keyHolders = append(scan.keyHolders, new(interface{}))
+	}
+	for _, join := range n.joins {
+		scan.joins = append(scan.joins, join.node.newScan())
+	}
+	return scan
+}
+
+// targets returns the scan targets in the order of the columns of `planNode.selectList`
+func (s *nodeScan) targets() []interface{} {
+	targets := []interface{}{}
+	for _, fieldHolder := range s.fieldHolders {
+		targets = append(targets, fieldHolder.Interface())
+	}
+	for _, keyHolder := range s.keyHolders {
+		targets = append(targets, keyHolder)
+	}
+	for _, join := range s.joins {
+		targets = append(targets, join.targets()...)
+	}
+	return targets
+}
+
+func (s *nodeScan) key(column string) interface{} {
+	for i, keyColumn := range s.node.keyColumns {
+		if keyColumn == column {
+			return *s.keyHolders[i]
+		}
+	}
+	return nil
+}
+
+// assign sets the scanned values to the struct value, including the structs of joined rows that exist, and records the instances of the nodes
+func (s *nodeScan) assign(value reflect.Value, instances map[*planNode][]planInstance) planInstance {
+	for i, structField := range s.node.fields {
+		if fieldPtr := s.fieldHolders[i].Elem(); !fieldPtr.IsNil() {
+			value.Field(structField.Index).Set(fieldPtr.Elem())
+		}
+	}
+	instance := planInstance{value: value, keys: map[string]interface{}{}}
+	for i, keyColumn := range s.node.keyColumns {
+		instance.keys[keyColumn] = *s.keyHolders[i]
+	}
+	instances[s.node] = append(instances[s.node], instance)
+	for i, join := range s.node.joins {
+		joinScan := s.joins[i]
+		if joinScan.key(join.relation.childKey()) == nil {
+			continue
+		}
+		fieldValue := value.Field(join.field.Index)
+		if fieldValue.Kind() == reflect.Ptr {
+			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			fieldValue = fieldValue.Elem()
+		}
+		joinScan.assign(fieldValue, instances)
+	}
+	return instance
+}
+
+// relatedValue returns the addressable struct value as a value of the type, which is either the struct type or a pointer to it
+func relatedValue(t reflect.Type, value reflect.Value) reflect.Value {
+	if t.Kind() == reflect.Ptr {
+		return value.Addr()
+	}
+	return value
+}
+
+// normalizeKey returns a key that can be compared, since drivers may return byte slices for textual keys
+func normalizeKey(key interface{}) interface{} {
+	if bytes, ok := key.([]byte); ok {
+		return string(bytes)
+	}
+	return key
+}
@@ -0,0 +1,110 @@
+package fragmentsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+func TestPlan(t *testing.T) {
+	type User struct {
+		ID   int64  `db:"id" json:"id"`
+		Name string `db:"name" json:"name"`
+	}
+	type Comment struct {
+		ID       int64  `db:"id" json:"id"`
+		Body     string `db:"body" json:"body"`
+		AuthorID int64  `db:"author_id" json:"authorId"`
+		Author   *User  `json:"author" fragment:"rel=belongsto,table=users,fk=author_id,load=batch"`
+	}
+	type Post struct {
+		ID       int64     `db:"id" json:"id"`
+		Title    string    `db:"title" json:"title"`
+		AuthorID *int64    `db:"author_id" json:"authorId"`
+		Author   *User     `json:"author" fragment:"rel=belongsto,table=users,fk=author_id"`
+		Comments []Comment `json:"comments" fragment:"rel=hasmany,table=comments,fk=post_id"`
+	}
+	postsQuery := `SELECT "posts"."title", "posts"."id", "posts_author"."name", "posts_author"."id" FROM "posts" LEFT JOIN "users" AS "posts_author" ON "posts_author"."id" = "posts"."author_id"`
+	commentsQuery := `SELECT "comments"."body", "comments"."author_id", "comments"."post_id" FROM "comments" WHERE "comments"."post_id" IN ($1, $2)`
+	usersQuery := `SELECT "users"."name", "users"."id" FROM "users" WHERE "users"."id" IN ($1, $2)`
+	handler := func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		switch query {
+		case postsQuery + ` WHERE "posts"."id" > $1`:
+			return []string{"title", "id", "name", "id"}, [][]driver.Value{{"Hello", int64(1), "Ada", int64(10)}, {"World", int64(2), nil, nil}}, nil
+		case commentsQuery:
+			if fmt.Sprint(args) != "[1 2]" {
+				return nil, nil, errors.New("unexpected arguments " + fmt.Sprint(args))
+			}
+			return []string{"body", "author_id", "post_id"}, [][]driver.Value{{"First", int64(10), int64(1)}, {"Second", int64(11), int64(1)}}, nil
+		case usersQuery:
+			return []string{"name", "id"}, [][]driver.Value{{"Ada", int64(10)}, {"Charles", int64(11)}}, nil
+		}
+		return nil, nil, errors.New("unexpected query " + query)
+	}
+	f, _ := fragment.ParseStruct(Post{}, "title, author { name }, comments { body, author { name } }")
+	t.Run("plans joins", func(t *testing.T) {
+		plan, err := NewPlan(f, "posts", Postgres)
+		if err != nil {
+			t.Error("did not expect `NewPlan` to return error: " + err.Error())
+			return
+		}
+		if query := plan.SQL(); query != postsQuery {
+			t.Error("unexpected query " + query)
+		}
+	})
+	t.Run("loads relations in batches", func(t *testing.T) {
+		db, queries := openFakeDB(handler)
+		defer db.Close()
+		plan, err := NewPlan(f, "posts", Postgres)
+		if err != nil {
+			t.Error("did not expect `NewPlan` to return error: " + err.Error())
+			return
+		}
+		posts := []*Post{}
+		if err := plan.Query(context.Background(), db, &posts, `WHERE "posts"."id" > $1`, 0); err != nil {
+			t.Error("did not expect `Query` to return error: " + err.Error())
+			return
+		}
+		if len(queries()) != 3 {
+			t.Error(fmt.Sprint("expected 3 queries, got ", len(queries())))
+		}
+		if len(posts) != 2 || posts[0].Title != "Hello" || posts[1].Title != "World" {
+			t.Error("unexpected posts")
+			return
+		}
+		if posts[0].Author == nil || posts[0].Author.Name != "Ada" || posts[1].Author != nil {
+			t.Error("expected joined authors to be stitched")
+		}
+		if len(posts[0].Comments) != 2 || posts[0].Comments[1].Body != "Second" || posts[1].Comments == nil || len(posts[1].Comments) != 0 {
+			t.Error("expected comments to be stitched")
+			return
+		}
+		if posts[0].Comments[0].Author == nil || posts[0].Comments[0].Author.Name != "Ada" || posts[0].Comments[1].Author.Name != "Charles" {
+			t.Error("expected authors of comments to be stitched")
+		}
+	})
+	t.Run("does not load relations of undefined fragments", func(t *testing.T) {
+		plan, err := NewPlan(fragment.NewStruct(Post{}), "posts", SQLite)
+		if err != nil {
+			t.Error("did not expect `NewPlan` to return error: " + err.Error())
+			return
+		}
+		if query := plan.SQL(); query != `SELECT "posts"."id", "posts"."title", "posts"."author_id" FROM "posts"` {
+			t.Error("unexpected query " + query)
+		}
+	})
+	t.Run("validates relations", func(t *testing.T) {
+		type Invalid struct {
+			ID    int64  `db:"id"`
+			Posts []Post `fragment:"rel=hasmany,table=posts,load=join,fk=user_id"`
+		}
+		f, _ := fragment.ParseStruct(Invalid{}, "Posts")
+		if _, err := NewPlan(f, "invalid", SQLite); err == nil {
+			t.Error("expected `NewPlan` to return error for joined to-many relation")
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package fragmentsql
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// Kinds of relations, as specified by the `rel` option of the `fragment` tag
+const (
+	// BelongsTo is a to-one relation where the foreign key is a column of the table of the struct, e.g. `posts.author_id` referencing `users.id`
+	BelongsTo = "belongsto"
+	// HasOne is a to-one relation where the foreign key is a column of the related table, e.g. `profiles.user_id` referencing `users.id`
+	HasOne = "hasone"
+	// HasMany is a to-many relation where the foreign key is a column of the related table, e.g. `comments.post_id` referencing `posts.id`
+	HasMany = "hasmany"
+)
+
+// Strategies of loading relations, as specified by the `load` option of the `fragment` tag
+const (
+	// LoadJoin loads a to-one relation using a `LEFT JOIN` in the query of the struct
+	LoadJoin = "join"
+	// LoadBatch loads a relation using a second query for every struct of a query, keyed by the foreign key
+	LoadBatch = "batch"
+)
+
+// relation is the relation of a struct field as specified by its `fragment` tag,
+// e.g. `fragment:"rel=belongsto,table=users,fk=author_id"` or `fragment:"rel=hasmany,table=comments,fk=post_id,load=batch"`
+type relation struct {
+	kind  string
+	load  string
+	table string
+	// fk is the foreign key column, which is a column of the table of the struct for `belongsto` relations, and otherwise of the related table
+	fk string
+	// ref is the column referenced by the foreign key, which defaults to "id"
+	ref string
+}
+
+// relationOf returns the relation of a struct field as specified by the options of its `fragment` tag, and false if it does not specify a relation.
+// The options are `rel` (`belongsto`, `hasone`, or `hasmany`), `table`, `fk`, `ref` (defaults to "id"), and `load` (`join` or `batch`),
+// where `load` defaults to `batch` for `hasmany` relations, which cannot be joined, and otherwise `join`.
+func relationOf(structField typemeta.StructField) (relation, bool, error) {
+	fragmentTag := structField.Tag("fragment")
+	if fragmentTag == nil {
+		return relation{}, false, nil
+	}
+	r := relation{}
+	for _, option := range append([]string{fragmentTag.Name}, fragmentTag.Options...) {
+		equalsIndex := strings.Index(option, "=")
+		if equalsIndex == -1 {
+			continue
+		}
+		value := option[equalsIndex+1:]
+		switch option[:equalsIndex] {
+		case "rel":
+			r.kind = value
+		case "load":
+			r.load = value
+		case "table":
+			r.table = value
+		case "fk":
+			r.fk = value
+		case "ref":
+			r.ref = value
+		}
+	}
+	if r.kind == "" {
+		return r, false, nil
+	}
+	fieldErr := func(message string) error {
+		return errors.New("invalid relation of field \"" + structField.String() + "\": " + message)
+	}
+	if r.kind != BelongsTo && r.kind != HasOne && r.kind != HasMany {
+		return r, true, fieldErr("unrecognized relation \"" + r.kind + "\"")
+	} else if r.table == "" {
+		return r, true, fieldErr("missing table")
+	} else if r.fk == "" {
+		return r, true, fieldErr("missing foreign key")
+	}
+	if r.ref == "" {
+		r.ref = "id"
+	}
+	if r.load == "" {
+		if r.kind == HasMany {
+			r.load = LoadBatch
+		} else {
+			r.load = LoadJoin
+		}
+	}
+	if r.load != LoadJoin && r.load != LoadBatch {
+		return r, true, fieldErr("unrecognized load strategy \"" + r.load + "\"")
+	} else if r.kind == HasMany && r.load == LoadJoin {
+		return r, true, fieldErr("to-many relations cannot be joined")
+	}
+	fieldTypeMeta := structField.TypeMeta
+	if r.kind == HasMany {
+		if _, ok := fieldTypeMeta.(*typemeta.Slice); !ok {
+			return r, true, fieldErr("expected slice for to-many relation")
+		}
+		fieldTypeMeta = typemeta.ElemOf(fieldTypeMeta)
+	}
+	if ptrTypeMeta, ok := fieldTypeMeta.(*typemeta.Ptr); ok {
+		fieldTypeMeta = ptrTypeMeta.Elem
+	}
+	if _, ok := fieldTypeMeta.(*typemeta.Struct); !ok {
+		return r, true, fieldErr("expected struct type of related table")
+	}
+	return r, true, nil
+}
+
+// parentKey returns the column of the table of the struct by which related rows are keyed
+func (r relation) parentKey() string {
+	if r.kind == BelongsTo {
+		return r.fk
+	}
+	return r.ref
+}
+
+// childKey returns the column of the related table by which related rows are keyed
+func (r relation) childKey() string {
+	if r.kind == BelongsTo {
+		return r.ref
+	}
+	return r.fk
+}
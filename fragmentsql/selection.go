@@ -0,0 +1,138 @@
+package fragmentsql
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+
+	fragment "github.com/ludvigalden/go-fragment"
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// Selection is the columns of the fields included in a struct fragment that have a `db` tag, which can be used to select and scan only those columns.
+type Selection struct {
+	typeMeta *typemeta.Struct
+	dialect  Dialect
+	table    string
+	fields   []typemeta.StructField
+}
+
+// Select returns the selection of the columns of the fields included in the fragment that have a `db` tag, e.g. `db:"display_name"`.
+// Fields with the tag `db:"-"` or without a `db` tag are not selected. If a table is passed, the columns are qualified with it.
+// An error is returned if the fragment is invalid or does not include any fields with a `db` tag.
+func Select(f fragment.Struct, dialect Dialect, table ...string) (Selection, error) {
+	if !f.IsValid() {
+		return Selection{}, errors.New("cannot select columns of invalid fragment")
+	}
+	selection := Selection{typeMeta: f.TypeMeta(), dialect: dialect}
+	if len(table) > 0 {
+		selection.table = table[0]
+	}
+	f.IterateFields(func(field fragment.StructField) {
+		if _, ok := columnOf(field.StructField); ok {
+			selection.fields = append(selection.fields, field.StructField)
+		}
+	})
+	if len(selection.fields) == 0 {
+		return selection, errors.New("fragment of " + f.TypeMeta().String() + " does not include any fields with a `db` tag")
+	}
+	return selection, nil
+}
+
+// Columns returns the unquoted names of the selected columns
+func (s Selection) Columns() []string {
+	columns := make([]string, len(s.fields))
+	for i, structField := range s.fields {
+		columns[i], _ = columnOf(structField)
+	}
+	return columns
+}
+
+// QuotedColumns returns the selected columns quoted using the dialect, and qualified with the table of the selection if any
+func (s Selection) QuotedColumns() []string {
+	columns := s.Columns()
+	for i, column := range columns {
+		if s.table != "" {
+			column = s.table + "." + column
+		}
+		columns[i] = s.dialect.QuoteIdentifier(column)
+	}
+	return columns
+}
+
+// Expr returns the select list of the selected columns, e.g. `"id", "title"`
+func (s Selection) Expr() string {
+	return strings.Join(s.QuotedColumns(), ", ")
+}
+
+// Scan scans the current row of the rows into the selected fields of the destination, which must be a pointer to a struct
+// of the type of the fragment. The rows must have been queried with the columns of the selection, e.g. using `Expr`.
+func (s Selection) Scan(rows *sql.Rows, dest interface{}) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() || destValue.Elem().Type() != s.typeMeta.Type() {
+		return errors.New("expected destination to be a non-nil pointer to " + s.typeMeta.String() + ", but received " + typemeta.Get(dest).String())
+	}
+	return rows.Scan(s.scanTargets(destValue.Elem())...)
+}
+
+// ScanAll scans every remaining row of the rows into new elements appended to the destination, which must be a pointer to a slice of structs,
+// or of pointers to structs, of the type of the fragment. The rows are closed when all rows have been scanned.
+func (s Selection) ScanAll(rows *sql.Rows, dest interface{}) error {
+	defer rows.Close()
+	sliceValue, elemIsPtr, err := sliceDest(dest, s.typeMeta)
+	if err != nil {
+		return err
+	}
+	elemType := s.typeMeta.Type()
+	for rows.Next() {
+		elemPtr := reflect.New(elemType)
+		if err := rows.Scan(s.scanTargets(elemPtr.Elem())...); err != nil {
+			return err
+		}
+		if elemIsPtr {
+			sliceValue = reflect.Append(sliceValue, elemPtr)
+		} else {
+			sliceValue = reflect.Append(sliceValue, elemPtr.Elem())
+		}
+	}
+	reflect.ValueOf(dest).Elem().Set(sliceValue)
+	return rows.Err()
+}
+
+// sliceDest returns the slice pointed to by the destination, which must be a pointer to a slice of structs, or of pointers to structs,
+// of the specified type, and whether the elements of the slice are pointers
+func sliceDest(dest interface{}, typeMeta *typemeta.Struct) (reflect.Value, bool, error) {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() || destValue.Elem().Kind() != reflect.Slice {
+		return reflect.Value{}, false, errors.New("expected destination to be a non-nil pointer to a slice, but received " + typemeta.Get(dest).String())
+	}
+	sliceValue := destValue.Elem()
+	elemType := sliceValue.Type().Elem()
+	elemIsPtr := elemType.Kind() == reflect.Ptr
+	if elemIsPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType != typeMeta.Type() {
+		return reflect.Value{}, false, errors.New("expected destination to be a pointer to a slice of " + typeMeta.String() + ", but received " + typemeta.Get(dest).String())
+	}
+	return sliceValue, elemIsPtr, nil
+}
+
+// scanTargets returns pointers to the selected fields of a struct value
+func (s Selection) scanTargets(structValue reflect.Value) []interface{} {
+	targets := make([]interface{}, len(s.fields))
+	for i, structField := range s.fields {
+		targets[i] = structValue.Field(structField.Index).Addr().Interface()
+	}
+	return targets
+}
+
+// columnOf returns the column of a struct field as specified by its `db` tag, and false if it does not have one
+func columnOf(structField typemeta.StructField) (string, bool) {
+	dbTag := structField.Tag("db")
+	if dbTag == nil || dbTag.Name == "" || dbTag.Name == "-" {
+		return "", false
+	}
+	return dbTag.Name, true
+}
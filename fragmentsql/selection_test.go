@@ -0,0 +1,84 @@
+package fragmentsql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+func TestSelection(t *testing.T) {
+	type User struct {
+		ID          int64   `db:"id" json:"id"`
+		DisplayName string  `db:"display_name" json:"displayName"`
+		Email       *string `db:"email" json:"email"`
+		Password    string  `db:"-" json:"-"`
+		Friends     []User  `json:"friends"`
+	}
+	t.Run("selects columns of included fields", func(t *testing.T) {
+		selection, err := Select(fragment.NewStruct(User{}), Postgres)
+		if err != nil {
+			t.Error("did not expect `Select` to return error: " + err.Error())
+			return
+		}
+		if expr := selection.Expr(); expr != `"id", "display_name", "email"` {
+			t.Error("unexpected select list " + expr)
+		}
+		f, _ := fragment.ParseStruct(User{}, "displayName, friends")
+		selection, err = Select(f, MySQL, "users")
+		if err != nil {
+			t.Error("did not expect `Select` to return error: " + err.Error())
+			return
+		}
+		if expr := selection.Expr(); expr != "`users`.`display_name`" {
+			t.Error("unexpected select list " + expr)
+		}
+		f, _ = fragment.ParseStruct(User{}, "friends")
+		if _, err := Select(f, SQLite); err == nil {
+			t.Error("expected `Select` to return error when no columns are selected")
+		}
+	})
+	t.Run("quotes identifiers", func(t *testing.T) {
+		if quoted := Postgres.QuoteIdentifier(`we"ird`); quoted != `"we""ird"` {
+			t.Error("unexpected quoted identifier " + quoted)
+		}
+		if quoted := MySQL.QuoteIdentifier("posts.title"); quoted != "`posts`.`title`" {
+			t.Error("unexpected quoted identifier " + quoted)
+		}
+	})
+	t.Run("scans selected fields", func(t *testing.T) {
+		db, queries := openFakeDB(func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+			if query != `SELECT "id", "email" FROM "users"` {
+				return nil, nil, errors.New("unexpected query " + query)
+			}
+			return []string{"id", "email"}, [][]driver.Value{{int64(1), "ada@example.com"}, {int64(2), nil}}, nil
+		})
+		defer db.Close()
+		f, _ := fragment.ParseStruct(User{}, "id, email")
+		selection, err := Select(f, SQLite)
+		if err != nil {
+			t.Error("did not expect `Select` to return error: " + err.Error())
+			return
+		}
+		rows, err := db.Query("SELECT " + selection.Expr() + ` FROM "users"`)
+		if err != nil {
+			t.Error("did not expect query to return error: " + err.Error())
+			return
+		}
+		users := []*User{}
+		if err := selection.ScanAll(rows, &users); err != nil {
+			t.Error("did not expect `ScanAll` to return error: " + err.Error())
+			return
+		}
+		if len(users) != 2 || users[0].ID != 1 || users[0].Email == nil || *users[0].Email != "ada@example.com" || users[1].Email != nil {
+			t.Error("unexpected scanned users")
+		}
+		if len(queries()) != 1 {
+			t.Error("expected a single query")
+		}
+		if err := selection.ScanAll(rows, &[]string{}); err == nil {
+			t.Error("expected `ScanAll` to return error for destination of wrong type")
+		}
+	})
+}
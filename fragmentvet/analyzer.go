@@ -0,0 +1,220 @@
+// Package fragmentvet provides an analyzer that checks the constant fragment expressions, field names, and paths passed to the fragment
+// package against the Go types that they are parsed for, so that unknown fields and invalid nesting are reported at build time rather than
+// returned as errors or panics at run time, e.g. by `AddByName` and `Pick`, which panic for fields that do not exist.
+// The module requires a tagged version of the fragment module, and is developed against local changes of it using an uncommitted workspace,
+// e.g. `go work init . ./fragmentvet` in the root of the repository.
+package fragmentvet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const fragmentPath = "github.com/ludvigalden/go-fragment"
+
+// Analyzer reports constant string arguments of `ParseStruct`, `Parse`, `ParseStructPath`, `NewStructPath`, and of the `AddByName`, `SetByName`,
+// `Pick`, and `Omit` methods of `Struct`, with fields that do not exist in the types that they are parsed for, or with fragments or selectors
+// of fields that cannot have them. The type of a `Struct` is resolved from the calls and variables that it is built by, e.g.
+// `fragment.NewEmptyStruct(User{}).AddByName("Name")`, and arguments whose types cannot be resolved are not checked.
+var Analyzer = &analysis.Analyzer{
+	Name:     "fragmentvet",
+	Doc:      "check constant fragment expressions, field names, and paths against the types that they are parsed for",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// structMethods is the methods of `Struct` that return a fragment of the same type as the receiver
+var structMethods = map[string]bool{
+	"Add": true, "AddByName": true, "AddExcluded": true, "AddExcludedByName": true, "Remove": true, "RemoveByName": true,
+	"Set": true, "SetByName": true, "AssignToField": true, "Omit": true, "Pick": true, "Assign": true, "AssignStruct": true,
+	"Copy": true, "Clear": true, "EnsureDefined": true,
+}
+
+type checker struct {
+	pass *analysis.Pass
+	// definitions is the expressions that variables are defined by, and the index of the value of the variable if the expression has multiple values
+	definitions map[*types.Var]definition
+}
+
+type definition struct {
+	expr  ast.Expr
+	index int
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	c := &checker{pass: pass, definitions: map[*types.Var]definition{}}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil)}, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			c.define(n.Lhs, n.Rhs)
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(n.Names))
+			for i, name := range n.Names {
+				lhs[i] = name
+			}
+			c.define(lhs, n.Values)
+		}
+	})
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		c.checkCall(n.(*ast.CallExpr))
+	})
+	return nil, nil
+}
+
+// define registers the expressions that variables are defined by. Variables that are assigned more than once are not resolved.
+func (c *checker) define(lhs []ast.Expr, rhs []ast.Expr) {
+	for i, expr := range lhs {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		v, ok := c.pass.TypesInfo.ObjectOf(ident).(*types.Var)
+		if !ok {
+			continue
+		}
+		if _, ok := c.definitions[v]; ok {
+			c.definitions[v] = definition{}
+		} else if len(rhs) == len(lhs) {
+			c.definitions[v] = definition{expr: rhs[i]}
+		} else if len(rhs) == 1 {
+			c.definitions[v] = definition{expr: rhs[0], index: i}
+		} else {
+			c.definitions[v] = definition{}
+		}
+	}
+}
+
+func (c *checker) checkCall(call *ast.CallExpr) {
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != fragmentPath || call.Ellipsis.IsValid() {
+		return
+	}
+	if recv := fn.Type().(*types.Signature).Recv(); recv == nil {
+		switch fn.Name() {
+		case "ParseStruct", "Parse":
+			if len(call.Args) < 2 {
+				return
+			}
+			t := c.pass.TypesInfo.TypeOf(call.Args[0])
+			for _, arg := range call.Args[1:] {
+				c.checkExpr(arg, t)
+			}
+		case "ParseStructPath", "NewStructPath":
+			if len(call.Args) < 2 {
+				return
+			}
+			c.checkPath(call.Args[0], call.Args[1:])
+		}
+		return
+	} else if !isFragmentStruct(recv.Type()) {
+		return
+	}
+	selector, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	t := c.structOfFragment(selector.X, map[*types.Var]bool{})
+	if t == nil {
+		return
+	}
+	switch fn.Name() {
+	case "AddByName", "AddExcludedByName", "RemoveByName":
+		for _, arg := range call.Args {
+			if name, ok := c.constantString(arg); ok {
+				if field := fieldByName(t, name); field == nil {
+					c.pass.Reportf(arg.Pos(), "field %q does not exist in %s", name, c.typeString(t))
+				}
+			}
+		}
+	case "SetByName":
+		if len(call.Args) != 2 {
+			return
+		}
+		name, ok := c.constantString(call.Args[0])
+		if !ok {
+			return
+		}
+		field := fieldByName(t, name)
+		if field == nil {
+			c.pass.Reportf(call.Args[0].Pos(), "field %q does not exist in %s", name, c.typeString(t))
+			return
+		}
+		c.checkExpr(call.Args[1], field.Type())
+	case "Pick", "Omit":
+		for _, arg := range call.Args {
+			c.checkExpr(arg, t)
+		}
+	}
+}
+
+// structOfFragment returns the struct type of a `Struct` expression, or nil if it cannot be resolved
+func (c *checker) structOfFragment(expr ast.Expr, visited map[*types.Var]bool) types.Type {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.CallExpr:
+		return c.structOfFragmentCall(expr, 0, visited)
+	case *ast.Ident:
+		v, ok := c.pass.TypesInfo.Uses[expr].(*types.Var)
+		if !ok || visited[v] || c.definitions[v].expr == nil {
+			return nil
+		}
+		visited[v] = true
+		d := c.definitions[v]
+		if call, ok := ast.Unparen(d.expr).(*ast.CallExpr); ok {
+			return c.structOfFragmentCall(call, d.index, visited)
+		} else if d.index == 0 {
+			return c.structOfFragment(d.expr, visited)
+		}
+	}
+	return nil
+}
+
+// structOfFragmentCall returns the struct type of the value at an index of the results of a call that returns a `Struct`, or nil if it cannot be resolved
+func (c *checker) structOfFragmentCall(call *ast.CallExpr, index int, visited map[*types.Var]bool) types.Type {
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != fragmentPath {
+		return nil
+	}
+	if fn.Type().(*types.Signature).Recv() == nil {
+		switch fn.Name() {
+		case "NewStruct", "NewEmptyStruct", "NewCompleteStruct", "ParseStruct", "Parse":
+			if t := c.pass.TypesInfo.TypeOf(call.Args[0]); index == 0 && resolvable(t) {
+				return structOf(t)
+			}
+		}
+		return nil
+	}
+	selector, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || !structMethods[fn.Name()] || index != 0 {
+		return nil
+	}
+	return c.structOfFragment(selector.X, visited)
+}
+
+// constantString returns the value of an expression if it is a constant string
+func (c *checker) constantString(expr ast.Expr) (string, bool) {
+	tv, ok := c.pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+func (c *checker) typeString(t types.Type) string {
+	return types.TypeString(t, types.RelativeTo(c.pass.Pkg))
+}
+
+// isFragmentStruct returns whether a type is `fragment.Struct` or a pointer to it
+func isFragmentStruct(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == fragmentPath && named.Obj().Name() == "Struct"
+}
@@ -0,0 +1,222 @@
+package fragmentvet
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+// checkExpr reports errors of an argument that is a constant fragment expression, such as "name, profile { bio }", parsed for a type
+func (c *checker) checkExpr(arg ast.Expr, t types.Type) {
+	expr, ok := c.constantString(arg)
+	if !ok || !resolvable(t) {
+		return
+	}
+	unstructured, err := fragment.ParseUnstructured(expr)
+	if err != nil {
+		c.pass.Reportf(arg.Pos(), "invalid fragment expression %q: %s", expr, err.Error())
+		return
+	} else if unstructured.IsUndefined() {
+		return
+	}
+	structType := structOf(t)
+	if structType == nil {
+		c.pass.Reportf(arg.Pos(), "cannot fragment non-struct type %s", c.typeString(t))
+		return
+	}
+	c.checkUnstructured(arg, structType, unstructured, nil)
+}
+
+func (c *checker) checkUnstructured(arg ast.Expr, structType types.Type, unstructured fragment.Unstructured, path []string) {
+	var fieldNames []string
+	fieldFragments := map[string]fragment.Fragment{}
+	unstructured.IterateFields(func(fieldName string, fieldFragment fragment.Fragment) {
+		fieldNames = append(fieldNames, fieldName)
+		fieldFragments[fieldName] = fieldFragment
+	})
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
+		if fieldName == "__typename" {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), fieldName)
+		field := fieldByName(structType, fieldName)
+		if field == nil {
+			c.pass.Reportf(arg.Pos(), "field %q does not exist in %s", strings.Join(fieldPath, "."), c.typeString(structType))
+			continue
+		}
+		fieldFragment, ok := fieldFragments[fieldName].(fragment.Unstructured)
+		if !ok || fieldFragment.IsUndefined() {
+			continue
+		}
+		fieldStructType := structOf(field.Type())
+		if fieldStructType == nil {
+			c.pass.Reportf(arg.Pos(), "field %q of non-struct type %s cannot have a fragment", strings.Join(fieldPath, "."), c.typeString(field.Type()))
+			continue
+		}
+		c.checkUnstructured(arg, fieldStructType, fieldFragment, fieldPath)
+	}
+}
+
+// checkPath reports errors of the arguments of a path for a type that are constant path expressions, such as `items[0].name`.
+// Arguments following an argument that is not a constant string are not checked, as the position of the path is not known.
+func (c *checker) checkPath(typeArg ast.Expr, args []ast.Expr) {
+	valueType := c.pass.TypesInfo.TypeOf(typeArg)
+	if !resolvable(valueType) {
+		return
+	}
+	structType := structOf(valueType)
+	if structType == nil {
+		c.pass.Reportf(typeArg.Pos(), "cannot create struct path for non-struct type %s", c.typeString(valueType))
+		return
+	}
+	for _, arg := range args {
+		expr, ok := c.constantString(arg)
+		if !ok {
+			return
+		}
+		path, err := fragment.ParseUnstructuredPath(expr)
+		if err != nil {
+			c.pass.Reportf(arg.Pos(), "invalid path expression %q: %s", expr, err.Error())
+			return
+		}
+		fieldNames, selectors := path.FieldNames(), path.Selectors()
+		for i := 0; i <= len(fieldNames); i++ {
+			if i > 0 {
+				fieldName := fieldNames[i-1]
+				if structType == nil {
+					c.pass.Reportf(arg.Pos(), "cannot select field %q of non-struct type %s", fieldName, c.typeString(valueType))
+					return
+				}
+				field := fieldByName(structType, fieldName)
+				if field == nil {
+					c.pass.Reportf(arg.Pos(), "field %q does not exist in %s", fieldName, c.typeString(structType))
+					return
+				}
+				valueType = field.Type()
+				structType = structOf(valueType)
+			}
+			if i >= len(selectors) {
+				continue
+			}
+			for _, selector := range selectors[i] {
+				elemType, ok := selectorElem(selector, valueType)
+				if !ok {
+					c.pass.Reportf(arg.Pos(), "cannot select %s of type %s", selector.Expr(), c.typeString(valueType))
+					return
+				}
+				valueType = elemType
+				structType = structOf(valueType)
+			}
+		}
+	}
+}
+
+// selectorElem returns the type of the elements that a selector selects of a type, as `PathSelector` does
+func selectorElem(selector fragment.PathSelector, t types.Type) (types.Type, bool) {
+	for {
+		ptr, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+		t = ptr.Elem()
+	}
+	switch t := t.Underlying().(type) {
+	case *types.Slice:
+		return t.Elem(), selector.Kind != fragment.PathSelectorKey
+	case *types.Array:
+		return t.Elem(), selector.Kind != fragment.PathSelectorKey
+	case *types.Map:
+		if selector.Kind == fragment.PathSelectorSlice {
+			return nil, false
+		} else if selector.Kind == fragment.PathSelectorIndex {
+			if basic, ok := t.Key().Underlying().(*types.Basic); !ok || basic.Info()&types.IsInteger == 0 {
+				return nil, false
+			}
+		}
+		return t.Elem(), true
+	}
+	return nil, false
+}
+
+// structOf returns the struct type of a type, or of the elements of a pointer, slice, array, or map type, as `typemeta.StructOf` does.
+// Structs that implement `json.Marshaler` are primitive and are not returned.
+func structOf(t types.Type) types.Type {
+	for t != nil {
+		switch u := t.Underlying().(type) {
+		case *types.Struct:
+			if types.NewMethodSet(t).Lookup(nil, "MarshalJSON") != nil {
+				return nil
+			}
+			return t
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		case *types.Map:
+			t = u.Elem()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// resolvable returns whether a type that is passed as the type of a fragment is the type itself, rather than interfaces and metadata of types,
+// such as `reflect.Type` and `*typemeta.Struct`, which cannot be resolved statically
+func resolvable(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		return false
+	} else if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		switch named.Obj().Pkg().Path() {
+		case "reflect", "github.com/ludvigalden/go-typemeta", fragmentPath:
+			return false
+		}
+	}
+	return true
+}
+
+// fieldByName returns the field of a struct type with a name that is either the name or the JSON name of the field, as `typemeta.Struct.FieldByName` does
+func fieldByName(t types.Type, name string) *types.Var {
+	structType, ok := t.Underlying().(*types.Struct)
+	if !ok || name == "" {
+		return nil
+	}
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		if field.Name() == name || jsonName(field, structType.Tag(i)) == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// jsonName returns the JSON name of a field, which is empty if the field is excluded from JSON
+func jsonName(field *types.Var, tag string) string {
+	jsonTag, ok := reflect.StructTag(tag).Lookup("json")
+	if !ok {
+		if field.Exported() {
+			return field.Name()
+		}
+		return ""
+	}
+	name := strings.Split(jsonTag, ",")[0]
+	if name == "-" {
+		return ""
+	} else if name == "" {
+		return field.Name()
+	}
+	return name
+}
@@ -0,0 +1,12 @@
+// Command fragmentvet runs the fragmentvet analyzer, which checks constant fragment expressions, field names, and paths against the
+// types that they are parsed for. It can be run directly, e.g. `fragmentvet ./...`, or by `go vet -vettool=$(which fragmentvet) ./...`.
+package main
+
+import (
+	"github.com/ludvigalden/go-fragment/fragmentvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(fragmentvet.Analyzer)
+}
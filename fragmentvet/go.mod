@@ -0,0 +1,15 @@
+module github.com/ludvigalden/go-fragment/fragmentvet
+
+go 1.25.0
+
+require (
+	github.com/ludvigalden/go-fragment v0.1.0
+	golang.org/x/tools v0.47.0
+)
+
+require (
+	github.com/fatih/structtag v1.2.0 // indirect
+	github.com/ludvigalden/go-typemeta v1.0.0 // indirect
+	golang.org/x/mod v0.37.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+)
@@ -0,0 +1,65 @@
+package a
+
+import (
+	"reflect"
+	"time"
+
+	fragment "github.com/ludvigalden/go-fragment"
+)
+
+type Profile struct {
+	Bio       string    `json:"bio"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type User struct {
+	Name    string            `json:"fullName"`
+	Email   string            `json:"-"`
+	Profile *Profile          `json:"profile"`
+	Posts   []Post            `json:"posts"`
+	Tags    map[string]string `json:"tags"`
+	secret  string
+}
+
+type Post struct {
+	Title string
+}
+
+func parse() {
+	fragment.ParseStruct(User{}, "fullName, Email, profile { bio }, secret, __typename")
+	fragment.ParseStruct(User{}, "nmae")                           // want `field "nmae" does not exist in User`
+	fragment.ParseStruct(&User{}, "profile { bio, website }")      // want `field "profile.website" does not exist in Profile`
+	fragment.ParseStruct([]User{}, "fullName { first }")           // want `field "fullName" of non-struct type string cannot have a fragment`
+	fragment.ParseStruct(User{}, "profile { createdAt { unix } }") // want `field "profile.createdAt" of non-struct type time.Time cannot have a fragment`
+	fragment.ParseStruct(User{}, "email")                          // want `field "email" does not exist in User`
+	fragment.ParseStruct(User{}, "profile { bio")                  // want `invalid fragment expression`
+	fragment.ParseStruct(User{}, " ")                              // want `invalid fragment expression`
+	fragment.ParseStruct(User{}, "profile { }")                    // want `invalid fragment expression`
+	fragment.ParseStruct(0, "name")                                // want `cannot fragment non-struct type int`
+	fragment.Parse(User{}, "posts { title }")                      // want `field "posts.title" does not exist in Post`
+	fragment.ParseStruct(reflect.TypeOf(User{}), "nmae")
+	var t interface{} = User{}
+	fragment.ParseStruct(t, "nmae")
+}
+
+func paths() {
+	fragment.NewStructPath(User{}, "posts[0]", "Title")
+	fragment.NewStructPath(User{}, `tags["en"]`)
+	fragment.NewStructPath(User{}, "posts[0].title")    // want `field "title" does not exist in Post`
+	fragment.NewStructPath(User{}, `posts["en"]`)       // want `cannot select \["en"\] of type \[\]Post`
+	fragment.ParseStructPath(User{}, "profile", "nmae") // want `field "nmae" does not exist in Profile`
+}
+
+func methods() {
+	fragment.NewEmptyStruct(User{}).AddByName("Name", "Nmae") // want `field "Nmae" does not exist in User`
+	f := fragment.NewStruct(User{}).AddByName("profile")
+	f.SetByName("Profile", "bio, website")                     // want `field "website" does not exist in Profile`
+	f.SetByName("Profil", "bio")                               // want `field "Profil" does not exist in User`
+	f.Pick("fullName, profile { bio }").Omit("posts { body }") // want `field "posts.body" does not exist in Post`
+	g, _ := fragment.ParseStruct(User{}, "fullName")
+	g.Pick("nmae") // want `field "nmae" does not exist in User`
+	f.ToType(Post{}).AddByName("Nmae")
+	h := fragment.NewEmptyStruct(User{})
+	h = h.ToType(Post{})
+	h.AddByName("Title")
+}
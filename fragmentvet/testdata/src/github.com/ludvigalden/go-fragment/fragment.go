@@ -0,0 +1,30 @@
+// Package fragment is a stub of the fragment package for the tests of the analyzer
+package fragment
+
+type Struct struct{}
+
+type StructPath struct{}
+
+func ParseStruct(t interface{}, f ...interface{}) (Struct, error) { return Struct{}, nil }
+
+func Parse(t interface{}, f interface{}) (Struct, error) { return Struct{}, nil }
+
+func ParseStructPath(t interface{}, v ...interface{}) (StructPath, error) { return StructPath{}, nil }
+
+func NewStructPath(t interface{}, v ...interface{}) StructPath { return StructPath{} }
+
+func NewStruct(t interface{}) Struct { return Struct{} }
+
+func NewEmptyStruct(t interface{}) Struct { return Struct{} }
+
+func (f Struct) Add(fieldIndices ...int) Struct { return f }
+
+func (f Struct) AddByName(fieldNames ...string) Struct { return f }
+
+func (f Struct) SetByName(fieldName string, fieldFragment interface{}) Struct { return f }
+
+func (f Struct) Pick(v ...interface{}) Struct { return f }
+
+func (f Struct) Omit(v ...interface{}) Struct { return f }
+
+func (f Struct) ToType(t interface{}) Struct { return f }
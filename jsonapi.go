@@ -0,0 +1,142 @@
+package fragment
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// JSONAPIQuery is the sparse fieldsets and included relationships of a JSON:API request,
+// e.g. `fields[articles]=title,body&fields[people]=name&include=author,comments.author`.
+type JSONAPIQuery struct {
+	// Fields is the sparse fieldsets keyed by resource type. Resource types without a fieldset are not included.
+	Fields map[string]Struct
+	// Include is the relationship paths to include, e.g. `comments.author`. It is nil if the `include` parameter is not specified,
+	// and empty if it is specified as empty.
+	Include []UnstructuredPath
+}
+
+// ParseJSONAPIQuery parses the `fields[TYPE]` and `include` parameters of a JSON:API request. The sparse fieldsets are parsed
+// using `ParseStruct` against the Go types of the resource types, which are specified as a map from resource type to a value of the type,
+// e.g. `map[string]interface{}{"articles": Article{}, "people": Person{}}`. An error is returned if a fieldset is specified
+// for a resource type that is not specified, or if a fieldset includes a field that does not exist.
+func ParseJSONAPIQuery(query url.Values, resourceTypes map[string]interface{}) (JSONAPIQuery, error) {
+	jsonAPIQuery := JSONAPIQuery{}
+	for key, values := range query {
+		if !strings.HasPrefix(key, "fields[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		resourceType := key[len("fields[") : len(key)-1]
+		t, ok := resourceTypes[resourceType]
+		if !ok {
+			return jsonAPIQuery, NewError("unknown resource type \"" + resourceType + "\"").Register(key)
+		}
+		fieldNames := splitJSONAPIList(values)
+		fieldset, err := ParseStruct(t, fieldNames)
+		if err != nil {
+			return jsonAPIQuery, NewError(err).Register(key)
+		} else if !fieldset.IsValid() {
+			return jsonAPIQuery, NewError("cannot parse fieldset for non-struct type of resource type \"" + resourceType + "\"").Register(key)
+		} else if len(fieldNames) == 0 {
+			fieldset = fieldset.Clear()
+		}
+		if jsonAPIQuery.Fields == nil {
+			jsonAPIQuery.Fields = map[string]Struct{}
+		}
+		jsonAPIQuery.Fields[resourceType] = fieldset
+	}
+	if values, ok := query["include"]; ok {
+		jsonAPIQuery.Include = []UnstructuredPath{}
+		for _, include := range splitJSONAPIList(values) {
+			fieldNames := strings.Split(include, ".")
+			for _, fieldName := range fieldNames {
+				if fieldName == "" {
+					return jsonAPIQuery, NewError("invalid relationship path \"" + include + "\"").Register("include")
+				}
+			}
+			jsonAPIQuery.Include = append(jsonAPIQuery.Include, UnstructuredPath{fieldNames: fieldNames})
+		}
+	}
+	return jsonAPIQuery, nil
+}
+
+// ParseJSONAPIQueryString parses a raw query string, e.g. `fields[articles]=title,body&include=author`, see `ParseJSONAPIQuery`.
+func ParseJSONAPIQueryString(rawQuery string, resourceTypes map[string]interface{}) (JSONAPIQuery, error) {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return JSONAPIQuery{}, err
+	}
+	return ParseJSONAPIQuery(query, resourceTypes)
+}
+
+// Fieldset returns the sparse fieldset of a resource type, and false if no fieldset is specified for the resource type.
+func (q JSONAPIQuery) Fieldset(resourceType string) (Struct, bool) {
+	fieldset, ok := q.Fields[resourceType]
+	return fieldset, ok
+}
+
+// IncludeFragment returns the included relationship paths as a fragment, e.g. `{ author, comments { author } }`.
+// If no relationship paths are included, an empty fragment is returned.
+func (q JSONAPIQuery) IncludeFragment() Unstructured {
+	fragment := NewEmptyUnstructured()
+	for _, include := range q.Include {
+		if pathFragment := include.ToFragment(); pathFragment != nil {
+			fragment = fragment.Assign(pathFragment)
+		}
+	}
+	return fragment
+}
+
+// Values returns the `fields[TYPE]` and `include` parameters of the query. Fieldsets are presented as the JSON names of their immediate fields.
+func (q JSONAPIQuery) Values() url.Values {
+	values := url.Values{}
+	for resourceType, fieldset := range q.Fields {
+		fieldNames := []string{}
+		fieldset.IterateFields(func(field StructField) {
+			if field.JSONName != "" {
+				fieldNames = append(fieldNames, field.JSONName)
+			} else {
+				fieldNames = append(fieldNames, field.Name)
+			}
+		})
+		values.Set("fields["+resourceType+"]", strings.Join(fieldNames, ","))
+	}
+	if q.Include != nil {
+		includes := []string{}
+		for _, include := range q.Include {
+			includes = append(includes, strings.Join(include.FieldNames(), "."))
+		}
+		values.Set("include", strings.Join(includes, ","))
+	}
+	return values
+}
+
+// Encode returns the query as a URL-encoded query string with the brackets of `fields[TYPE]` unescaped, e.g. `fields[articles]=title,body&include=author`.
+func (q JSONAPIQuery) Encode() string {
+	values := q.Values()
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := []string{}
+	for _, key := range keys {
+		escapedKey := strings.NewReplacer("%5B", "[", "%5D", "]").Replace(url.QueryEscape(key))
+		escapedValue := strings.ReplaceAll(url.QueryEscape(values.Get(key)), "%2C", ",")
+		parts = append(parts, escapedKey+"="+escapedValue)
+	}
+	return strings.Join(parts, "&")
+}
+
+// splitJSONAPIList splits comma-separated parameter values, omitting empty names
+func splitJSONAPIList(values []string) []string {
+	names := []string{}
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,74 @@
+package fragment
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONAPIQuery(t *testing.T) {
+	type Person struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	type Comment struct {
+		Body   string  `json:"body"`
+		Author *Person `json:"author"`
+	}
+	type Article struct {
+		Title    string     `json:"title"`
+		Body     string     `json:"body"`
+		Author   *Person    `json:"author"`
+		Comments []*Comment `json:"comments"`
+	}
+	resourceTypes := map[string]interface{}{"articles": Article{}, "people": Person{}, "comments": Comment{}}
+	t.Run("parses sparse fieldsets and includes", func(t *testing.T) {
+		query, err := ParseJSONAPIQueryString("fields[articles]=title,body&fields[people]=name&include=author,comments.author", resourceTypes)
+		if err != nil {
+			t.Error("did not expect `ParseJSONAPIQueryString` to return error: " + err.Error())
+			return
+		}
+		articles, ok := query.Fieldset("articles")
+		if !ok || articles.Expr() != "{ Title, Body }" {
+			t.Error("unexpected fieldset for articles " + articles.Expr())
+		}
+		people, ok := query.Fieldset("people")
+		if !ok || people.Expr() != "{ Name }" {
+			t.Error("unexpected fieldset for people " + people.Expr())
+		}
+		if _, ok := query.Fieldset("comments"); ok {
+			t.Error("did not expect fieldset for comments")
+		}
+		if len(query.Include) != 2 || strings.Join(query.Include[1].FieldNames(), ".") != "comments.author" {
+			t.Error("unexpected includes")
+		}
+		if include := query.IncludeFragment(); include.FieldsLen() != 2 || !include.HasByName("author") || !include.HasPath("comments", "author") {
+			t.Error("expected include fragment to have included relationships")
+		}
+		if encoded := query.Encode(); encoded != "fields[articles]=title,body&fields[people]=name&include=author,comments.author" {
+			t.Error("unexpected encoded query " + encoded)
+		}
+	})
+	t.Run("parses empty fieldsets and includes", func(t *testing.T) {
+		query, err := ParseJSONAPIQueryString("fields[people]=&include=", resourceTypes)
+		if err != nil {
+			t.Error("did not expect `ParseJSONAPIQueryString` to return error: " + err.Error())
+			return
+		}
+		if people, ok := query.Fieldset("people"); !ok || !people.IsEmpty() {
+			t.Error("expected empty fieldset for people")
+		}
+		if query.Include == nil || len(query.Include) != 0 {
+			t.Error("expected empty includes")
+		}
+		if query, _ := ParseJSONAPIQueryString("", resourceTypes); query.Include != nil || query.Fields != nil {
+			t.Error("expected unspecified fieldsets and includes")
+		}
+	})
+	t.Run("returns errors", func(t *testing.T) {
+		for _, rawQuery := range []string{"fields[books]=title", "fields[people]=age", "include=comments..author"} {
+			if _, err := ParseJSONAPIQueryString(rawQuery, resourceTypes); err == nil {
+				t.Error("expected `ParseJSONAPIQueryString` to return error for " + rawQuery)
+			}
+		}
+	})
+}
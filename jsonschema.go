@@ -0,0 +1,151 @@
+package fragment
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect of the schemas returned by `JSONSchema`
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the JSON that `MarshalJSON` produces for values of the type of the fragment,
+// i.e. the JSON presented by `PickJSON` using `DefaultPolicy`. Properties are named by the JSON names of the fields. Fields that may be JSON-null
+// are required and nullable if the fragment is defined, and optional if it is undefined, as they are then omitted instead. Structs picked using
+// undefined fragments are described in `$defs`, so that recursive types can be described.
+func JSONSchema(fragment Struct) ([]byte, error) {
+	schema, err := jsonSchemaOf(fragment)
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = JSONSchemaDialect
+	return json.Marshal(schema)
+}
+
+// jsonSchemaOf returns the JSON Schema of a fragment as a map, where definitions are referenced as `#/$defs/NAME`
+func jsonSchemaOf(fragment Struct) (map[string]interface{}, error) {
+	if !fragment.IsValid() {
+		return nil, errors.New("cannot describe JSON of invalid fragment")
+	}
+	b := newJSONShapeBuilder()
+	var shape *jsonShape
+	var err error
+	if fragment.IsUndefined() {
+		shape, err = b.definitionShape(fragment.TypeMeta())
+	} else {
+		shape, err = b.structShape(fragment)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if shape.kind == jsonShapeRef && len(b.definitions) == 1 {
+		// the definition is not referenced by itself and is inlined
+		if schema := shapeJSONSchema(b.definitions[shape.ref], jsonSchemaDefsPrefix); !containsJSONSchemaRef(schema, shape.ref) {
+			return schema, nil
+		}
+	}
+	schema := shapeJSONSchema(shape, jsonSchemaDefsPrefix)
+	if len(b.definitions) > 0 {
+		defs := map[string]interface{}{}
+		for _, name := range b.definitionNamesSorted() {
+			defs[name] = shapeJSONSchema(b.definitions[name], jsonSchemaDefsPrefix)
+		}
+		schema["$defs"] = defs
+	}
+	return schema, nil
+}
+
+const jsonSchemaDefsPrefix = "#/$defs/"
+
+// shapeJSONSchema returns the JSON Schema of a shape as a map, where definitions are referenced by the prefix followed by their names
+func shapeJSONSchema(shape *jsonShape, refPrefix string) map[string]interface{} {
+	schema := map[string]interface{}{}
+	var typeName string
+	switch shape.kind {
+	case jsonShapeObject:
+		typeName = "object"
+		properties := map[string]interface{}{}
+		required := []string{}
+		for _, property := range shape.properties {
+			properties[property.name] = shapeJSONSchema(property.shape, refPrefix)
+			if property.required {
+				required = append(required, property.name)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		schema["additionalProperties"] = false
+	case jsonShapeMap:
+		typeName = "object"
+		schema["additionalProperties"] = shapeJSONSchema(shape.elem, refPrefix)
+	case jsonShapeArray:
+		typeName = "array"
+		schema["items"] = shapeJSONSchema(shape.elem, refPrefix)
+		if shape.length >= 0 {
+			schema["minItems"] = shape.length
+			schema["maxItems"] = shape.length
+		}
+	case jsonShapeString:
+		typeName = "string"
+		switch shape.format {
+		case "byte":
+			schema["contentEncoding"] = "base64"
+		case "date-time":
+			schema["format"] = shape.format
+		}
+	case jsonShapeInteger:
+		typeName = "integer"
+		if shape.unsigned {
+			schema["minimum"] = 0
+		}
+	case jsonShapeNumber:
+		typeName = "number"
+	case jsonShapeBoolean:
+		typeName = "boolean"
+	case jsonShapeRef:
+		schema["$ref"] = refPrefix + shape.ref
+	}
+	if shape.enum != nil {
+		enum := shape.enum
+		if shape.nullable {
+			enum = append(append([]interface{}{}, enum...), nil)
+		}
+		schema["enum"] = enum
+	}
+	if typeName != "" {
+		if shape.nullable {
+			schema["type"] = []string{typeName, "null"}
+		} else {
+			schema["type"] = typeName
+		}
+	} else if shape.nullable && shape.kind == jsonShapeRef {
+		schema = map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+	}
+	if shape.description != "" {
+		schema["description"] = shape.description
+	}
+	return schema
+}
+
+// containsJSONSchemaRef returns whether a JSON Schema references a definition
+func containsJSONSchemaRef(schema interface{}, name string) bool {
+	switch schema := schema.(type) {
+	case map[string]interface{}:
+		if schema["$ref"] == jsonSchemaDefsPrefix+name {
+			return true
+		}
+		for _, v := range schema {
+			if containsJSONSchemaRef(v, name) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range schema {
+			if containsJSONSchemaRef(v, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package fragment
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	type Profile struct {
+		Bio string `json:"bio"`
+	}
+	type User struct {
+		ID      int64      `json:"id"`
+		Name    string     `json:"name"`
+		Age     uint       `json:"age,omitempty"`
+		Profile *Profile   `json:"profile"`
+		Friends []*User    `json:"friends"`
+		Tags    [2]string  `json:"tags"`
+		Private string     `json:"-"`
+		Posts   []struct{} `json:"posts"`
+	}
+	t.Run("describes defined fragment", func(t *testing.T) {
+		f, err := ParseStruct(User{}, "id, name, age, profile { bio }, friends { name }")
+		if err != nil {
+			t.Error("did not expect `ParseStruct` to return error: " + err.Error())
+			return
+		}
+		bytes, err := JSONSchema(f)
+		if err != nil {
+			t.Error("did not expect `JSONSchema` to return error: " + err.Error())
+			return
+		}
+		expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","additionalProperties":false,"properties":{` +
+			`"age":{"minimum":0,"type":["integer","null"]},` +
+			`"friends":{"items":{"additionalProperties":false,"properties":{"name":{"type":["string","null"]}},"required":["name"],"type":["object","null"]},"type":["array","null"]},` +
+			`"id":{"type":"integer"},` +
+			`"name":{"type":["string","null"]},` +
+			`"profile":{"additionalProperties":false,"properties":{"bio":{"type":["string","null"]}},"required":["bio"],"type":["object","null"]}},` +
+			`"required":["id","name","age","profile","friends"],"type":"object"}`
+		if string(bytes) != expected {
+			t.Error("unexpected schema " + string(bytes))
+		}
+	})
+	t.Run("describes undefined fragment with definitions", func(t *testing.T) {
+		schema, err := jsonSchemaOf(NewStruct(User{}))
+		if err != nil {
+			t.Error("did not expect `jsonSchemaOf` to return error: " + err.Error())
+			return
+		}
+		if schema["$ref"] != "#/$defs/User" {
+			t.Error("expected recursive type to be referenced")
+		}
+		defs, _ := schema["$defs"].(map[string]interface{})
+		user, _ := defs["User"].(map[string]interface{})
+		if required, _ := user["required"].([]string); len(required) != 1 || required[0] != "id" {
+			t.Error("expected fields that may be null to be optional")
+		}
+		properties, _ := user["properties"].(map[string]interface{})
+		if _, ok := properties["-"]; ok {
+			t.Error("did not expect excluded field")
+		}
+		friends, _ := properties["friends"].(map[string]interface{})
+		if items, _ := friends["items"].(map[string]interface{}); items["anyOf"] == nil {
+			t.Error("expected pointer elements to be nullable")
+		}
+		if tags, _ := properties["tags"].(map[string]interface{}); tags["minItems"] != 2 || tags["type"] != "array" {
+			t.Error("expected array to have length")
+		}
+		if _, err := json.Marshal(schema); err != nil {
+			t.Error("did not expect schema to fail to marshal: " + err.Error())
+		}
+	})
+	t.Run("describes values marshaled by their own methods", func(t *testing.T) {
+		type Event struct {
+			Payload json.RawMessage `json:"payload"`
+			Address net.IP          `json:"address"`
+			Data    []byte          `json:"data"`
+		}
+		bytes, err := JSONSchema(NewStruct(Event{}).AddByName("payload", "address", "data"))
+		if err != nil {
+			t.Error("did not expect `JSONSchema` to return error: " + err.Error())
+			return
+		}
+		expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","additionalProperties":false,"properties":{` +
+			`"address":{"type":["string","null"]},` +
+			`"data":{"contentEncoding":"base64","type":["string","null"]},` +
+			`"payload":{}},` +
+			`"required":["payload","address","data"],"type":"object"}`
+		if string(bytes) != expected {
+			t.Error("unexpected schema " + string(bytes))
+		}
+	})
+	t.Run("returns error for invalid fragment", func(t *testing.T) {
+		if _, err := JSONSchema(Struct{}); err == nil {
+			t.Error("expected `JSONSchema` to return error")
+		}
+	})
+}
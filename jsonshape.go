@@ -0,0 +1,298 @@
+package fragment
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// jsonShapeKind is the kind of JSON value described by a JSON shape
+type jsonShapeKind int
+
+const (
+	jsonShapeAny jsonShapeKind = iota
+	jsonShapeObject
+	jsonShapeMap
+	jsonShapeArray
+	jsonShapeString
+	jsonShapeInteger
+	jsonShapeNumber
+	jsonShapeBoolean
+	jsonShapeRef
+)
+
+// jsonShape describes the JSON values that `PickJSON` presents for a type and a fragment using `DefaultPolicy`, from which schemas and types are generated
+type jsonShape struct {
+	kind     jsonShapeKind
+	nullable bool
+	// properties is the properties of objects in the order of the struct fields
+	properties []jsonShapeProperty
+	// elem is the shape of the items of arrays and the values of maps
+	elem *jsonShape
+	// length is the length of arrays presented for Go arrays, and -1 for slices
+	length int
+	// ref is the name of the definition of references
+	ref         string
+	enum        []interface{}
+	format      string
+	unsigned    bool
+	description string
+	// typeMeta is the struct of objects picked using fragments
+	typeMeta *typemeta.Struct
+}
+
+type jsonShapeProperty struct {
+	name     string
+	shape    *jsonShape
+	required bool
+}
+
+// withNullable returns a copy of the shape that is nullable
+func (s *jsonShape) withNullable(nullable bool) *jsonShape {
+	if s.nullable == nullable {
+		return s
+	}
+	c := *s
+	c.nullable = nullable
+	return &c
+}
+
+// jsonShapeBuilder builds JSON shapes, where the shapes of structs picked with undefined fragments are definitions, so that recursive types can be described
+type jsonShapeBuilder struct {
+	definitions     map[string]*jsonShape
+	definitionNames map[reflect.Type]string
+	// raw is the struct types whose shapes as marshaled by `encoding/json` are being built, to stop at recursive types
+	raw map[reflect.Type]bool
+	// full is whether the definitions are the shapes of structs picked with all fields defined, i.e. where every field is presented and
+	// fields that may be JSON-null are presented as null, which are the full types of which the shapes of defined fragments are subsets
+	full bool
+	// suffix is the suffix of the names of definitions
+	suffix string
+}
+
+func newJSONShapeBuilder() *jsonShapeBuilder {
+	return &jsonShapeBuilder{definitions: map[string]*jsonShape{}, definitionNames: map[reflect.Type]string{}, raw: map[reflect.Type]bool{}}
+}
+
+// definitionNamesSorted returns the names of the definitions in order
+func (b *jsonShapeBuilder) definitionNamesSorted() []string {
+	names := make([]string, 0, len(b.definitions))
+	for name := range b.definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// structShape returns the shape of the object that `PickJSON` presents for a struct and a fragment. Fields that may be JSON-null are presented as null
+// if the fragment is defined, and omitted otherwise, which makes them nullable but required for defined fragments, and optional but non-null for undefined fragments.
+func (b *jsonShapeBuilder) structShape(fragment Struct) (*jsonShape, error) {
+	structTypeMeta := fragment.TypeMeta()
+	shape := &jsonShape{kind: jsonShapeObject, description: structTypeMeta.Description, length: -1, typeMeta: structTypeMeta}
+	explicit := b.full || !fragment.IsUndefined()
+	var fieldErr error
+	fragment.iterateFields(func(field StructField) {
+		if fieldErr != nil || field.JSONName == "" || field.Private {
+			return
+		}
+		fieldShape, err := b.fieldShape(field)
+		if err != nil {
+			fieldErr = NewError(err).Register(field.Name)
+			return
+		}
+		null := FieldCanBeJSONNull(field.StructField)
+		property := jsonShapeProperty{name: field.JSONName, shape: fieldShape.withNullable(explicit && null), required: explicit || !null}
+		if field.Description != "" {
+			described := *property.shape
+			described.description = field.Description
+			property.shape = &described
+		}
+		shape.properties = append(shape.properties, property)
+	}, b.full)
+	return shape, fieldErr
+}
+
+// fieldShape returns the shape of the value that `PickJSON` presents for a field, where struct values are picked using the fragment of the field
+func (b *jsonShapeBuilder) fieldShape(field StructField) (*jsonShape, error) {
+	if !IsFragmentable(field.TypeMeta) {
+		return b.rawShape(field.TypeMeta)
+	}
+	return b.pickedShape(field.TypeMeta, field.Fragment)
+}
+
+// pickedShape returns the shape of a value that is picked using a fragment, i.e. a struct, or a pointer, slice, or array of structs
+func (b *jsonShapeBuilder) pickedShape(typeMeta typemeta.TypeMeta, fragment Struct) (*jsonShape, error) {
+	switch t := typeMeta.(type) {
+	case *typemeta.Ptr:
+		shape, err := b.pickedShape(t.Elem, fragment)
+		if err != nil {
+			return nil, err
+		}
+		return shape.withNullable(true), nil
+	case *typemeta.Slice, *typemeta.Array:
+		elem := typemeta.ElemOf(t)
+		elemShape, err := b.pickedShape(elem, fragment)
+		if err != nil {
+			return nil, err
+		}
+		shape := &jsonShape{kind: jsonShapeArray, elem: elemShape.withNullable(elemShape.nullable || TypeCanBeJSONNull(elem.Type())), length: -1}
+		if array, ok := t.(*typemeta.Array); ok {
+			shape.length = array.Type().Len()
+		}
+		return shape, nil
+	case *typemeta.Struct:
+		if isNullableType(t.Type()) {
+			return b.rawShape(t)
+		} else if !fragment.IsUndefined() {
+			return b.structShape(fragment)
+		}
+		return b.definitionShape(t)
+	}
+	// maps of structs are not picked, but marshaled as is
+	return b.rawShape(typeMeta)
+}
+
+// definitionShape returns a reference to the definition of the shape of a struct picked using an undefined fragment
+func (b *jsonShapeBuilder) definitionShape(structTypeMeta *typemeta.Struct) (*jsonShape, error) {
+	if name, ok := b.definitionNames[structTypeMeta.Type()]; ok {
+		return &jsonShape{kind: jsonShapeRef, ref: name, length: -1}, nil
+	}
+	baseName := structTypeMeta.Name()
+	if baseName == "" {
+		baseName = "Struct"
+	}
+	name := baseName + b.suffix
+	for i := 2; b.definitions[name] != nil; i++ {
+		name = baseName + strconv.Itoa(i) + b.suffix
+	}
+	b.definitionNames[structTypeMeta.Type()] = name
+	b.definitions[name] = &jsonShape{}
+	shape, err := b.structShape(Struct{typeMeta: structTypeMeta})
+	if err != nil {
+		return nil, err
+	}
+	b.definitions[name] = shape
+	return &jsonShape{kind: jsonShapeRef, ref: name, length: -1}, nil
+}
+
+// rawShape returns the shape of a value as marshaled by `encoding/json`, which is how `PickJSON` presents values that are not picked using fragments
+func (b *jsonShapeBuilder) rawShape(typeMeta typemeta.TypeMeta) (*jsonShape, error) {
+	if t := typeMeta.Type(); typeMeta.Kind() != reflect.Ptr && t != timeType && !isNullableType(t) {
+		// values marshaled by their own methods, such as `json.RawMessage` and `net.IP`, are not described by their kinds
+		if t.Implements(marshalerType) {
+			return &jsonShape{kind: jsonShapeAny, length: -1}, nil
+		} else if t.Implements(textMarshalerType) {
+			return &jsonShape{kind: jsonShapeString, length: -1}, nil
+		}
+	}
+	switch t := typeMeta.(type) {
+	case *typemeta.Ptr:
+		shape, err := b.rawShape(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return shape.withNullable(true), nil
+	case *typemeta.Slice:
+		if t.Elem.Kind() == reflect.Uint8 {
+			return &jsonShape{kind: jsonShapeString, format: "byte", nullable: true, length: -1}, nil
+		}
+		elemShape, err := b.rawShape(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonShape{kind: jsonShapeArray, elem: elemShape, nullable: true, length: -1}, nil
+	case *typemeta.Array:
+		elemShape, err := b.rawShape(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonShape{kind: jsonShapeArray, elem: elemShape, length: t.Type().Len()}, nil
+	case *typemeta.Map:
+		elemShape, err := b.rawShape(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonShape{kind: jsonShapeMap, elem: elemShape, nullable: true, length: -1}, nil
+	case *typemeta.Interface:
+		return &jsonShape{kind: jsonShapeAny, length: -1}, nil
+	case *typemeta.Struct:
+		if isNullableType(t.Type()) {
+			return b.nullableShape(t)
+		} else if b.raw[t.Type()] {
+			return &jsonShape{kind: jsonShapeAny, length: -1}, nil
+		}
+		b.raw[t.Type()] = true
+		defer delete(b.raw, t.Type())
+		shape := &jsonShape{kind: jsonShapeObject, description: t.Description, length: -1}
+		var fieldErr error
+		t.IterateFields(func(structField typemeta.StructField) {
+			if fieldErr != nil || structField.JSONName == "" || structField.Private {
+				return
+			}
+			fieldShape, err := b.rawShape(structField.TypeMeta)
+			if err != nil {
+				fieldErr = NewError(err).Register(structField.Name)
+				return
+			}
+			shape.properties = append(shape.properties, jsonShapeProperty{name: structField.JSONName, shape: fieldShape, required: !structField.JSONOmitEmpty})
+		})
+		return shape, fieldErr
+	case *typemeta.Primitive:
+		return primitiveShape(t), nil
+	}
+	return nil, errors.New("cannot describe JSON of type " + typeMeta.String())
+}
+
+// nullableShape returns the shape of a struct implementing `Nullable` or of a `sql.Null*` type, which is presented as its wrapped value. The wrapped value is
+// described if its type can be determined, as for structs such as `sql.NullString`, and is otherwise not described.
+func (b *jsonShapeBuilder) nullableShape(structTypeMeta *typemeta.Struct) (*jsonShape, error) {
+	valueTypeMeta, _ := NullableValueTypeMeta(structTypeMeta)
+	if valueTypeMeta == nil {
+		return &jsonShape{kind: jsonShapeAny, nullable: true, length: -1}, nil
+	}
+	shape, err := b.rawShape(valueTypeMeta)
+	if err != nil {
+		return nil, err
+	}
+	return shape.withNullable(true), nil
+}
+
+func primitiveShape(primitive *typemeta.Primitive) *jsonShape {
+	shape := &jsonShape{length: -1}
+	switch primitive.Kind() {
+	case reflect.Bool:
+		shape.kind = jsonShapeBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		shape.kind = jsonShapeInteger
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		shape.kind = jsonShapeInteger
+		shape.unsigned = true
+	case reflect.Float32, reflect.Float64:
+		shape.kind = jsonShapeNumber
+	case reflect.String:
+		shape.kind = jsonShapeString
+	case reflect.Struct:
+		if primitive.Type() == timeType {
+			shape.kind = jsonShapeString
+			shape.format = "date-time"
+		}
+	}
+	if enum := primitive.Enum(); enum != nil && shape.kind != jsonShapeAny {
+		enum.IterateValues(func(_ string, value interface{}) {
+			shape.enum = append(shape.enum, value)
+		})
+		sort.Slice(shape.enum, func(i, j int) bool {
+			return fmt.Sprint(shape.enum[i]) < fmt.Sprint(shape.enum[j])
+		})
+	}
+	return shape
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
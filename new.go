@@ -1,9 +1,6 @@
 package fragment
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/ludvigalden/go-typemeta"
 )
 
@@ -47,88 +44,20 @@ func NewEmptyUnstructured() Unstructured {
 	return Unstructured{map[string]Fragment{}}
 }
 
-// NewStructPath creates a new field path
+// NewStructPath creates a new field path. It panics if the path is invalid, see `ParseStructPath`.
 func NewStructPath(t interface{}, v ...interface{}) StructPath {
-	typeMeta := typemeta.StructOf(typemeta.Get(t))
-	if typeMeta == nil {
-		panic("cannot create struct path for non-struct type " + typemeta.Get(t).String())
-	}
-	path := StructPath{typeMeta: typeMeta, fieldIndices: []int{}}
-	currentTypeMeta := typeMeta
-	for _, v := range v {
-		if vstr, ok := v.(string); ok {
-			v = strings.Split(vstr, ".")
-		}
-		if vint, ok := v.(int); ok {
-			v = []int{vint}
-		}
-		switch v := v.(type) {
-		case []string:
-			for _, fieldName := range v {
-				field := currentTypeMeta.EnsureFieldByName(fieldName)
-				path.fieldIndices = append(path.fieldIndices, field.Index)
-				currentTypeMeta = typemeta.StructOf(field.TypeMeta)
-			}
-			break
-		case []int:
-			for _, fieldIndex := range v {
-				field := currentTypeMeta.EnsureField(fieldIndex)
-				path.fieldIndices = append(path.fieldIndices, field.Index)
-				currentTypeMeta = typemeta.StructOf(field.TypeMeta)
-			}
-			break
-		case StructPath:
-			if v.typeMeta.Type() != currentTypeMeta.Type() {
-				panic("expected struct path for type " + currentTypeMeta.String() + " but received " + v.typeMeta.String())
-			}
-			path.fieldIndices = append(path.fieldIndices, v.fieldIndices...)
-			currentTypeMeta = v.TailTypeMeta()
-			break
-		case Path:
-			for _, fieldName := range v.FieldNames() {
-				field := currentTypeMeta.EnsureFieldByName(fieldName)
-				path.fieldIndices = append(path.fieldIndices, field.Index)
-				currentTypeMeta = typemeta.StructOf(field.TypeMeta)
-			}
-			break
-		case Fragment:
-			tailFragment, err := ParseStruct(path.TailTypeMeta(), v)
-			if err != nil {
-				panic(err)
-			}
-			path.tailFragment = tailFragment
-			break
-		default:
-			panic("unrecognized path argument " + fmt.Sprint(v))
-		}
-	}
-	if len(path.fieldIndices) == 0 {
-		path.fieldIndices = nil
+	path, err := ParseStructPath(t, v...)
+	if err != nil {
+		panic(err)
 	}
 	return path
 }
 
-// NewUnstructuedPath creates a new field path
+// NewUnstructuedPath creates a new field path. It panics if the path is invalid, see `ParseUnstructuredPath`.
 func NewUnstructuedPath(v ...interface{}) UnstructuredPath {
-	path := UnstructuredPath{fieldNames: []string{}}
-	for _, v := range v {
-		if vstr, ok := v.(string); ok {
-			v = strings.Split(vstr, ".")
-		}
-		switch v := v.(type) {
-		case []string:
-			for _, fieldName := range v {
-				path.fieldNames = append(path.fieldNames, fieldName)
-			}
-			break
-		case Path:
-			path.fieldNames = append(path.fieldNames, v.FieldNames()...)
-			break
-		case Fragment:
-			path.tailFragment = v
-		default:
-			panic("unrecognized path argument " + fmt.Sprint(v))
-		}
+	path, err := ParseUnstructuredPath(v...)
+	if err != nil {
+		panic(err)
 	}
 	return path
 }
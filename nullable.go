@@ -0,0 +1,106 @@
+package fragment
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// Nullable can be implemented by types wrapping a value that may be null, such as `Optional[T]`-style structs. Values of such types are treated as leaves,
+// meaning that they are deemed to be undefined and JSON-null when `IsNull` returns true, and that `PickJSON` presents them as the wrapped value otherwise.
+// The `sql.Null*` types, such as `sql.NullString`, `sql.NullInt64`, and `sql.NullTime`, are treated in the same manner, where a nil `driver.Value` is null.
+// Values of other types implementing `driver.Valuer` but not `json.Marshaler` are null if `Value` returns nil, and are otherwise presented unchanged.
+type Nullable interface {
+	// IsNull returns whether the value is null
+	IsNull() bool
+	// NullableValue returns the wrapped value, which is only used if the value is not null
+	NullableValue() interface{}
+}
+
+// nullableValueOf returns the wrapped value of a value implementing `fragment.Nullable` or of a `sql.Null*` type, whether the value is null,
+// and true as the third return value if the value is either, or if it is a null value of another type implementing `driver.Valuer`.
+func nullableValueOf(v reflect.Value) (interface{}, bool, bool, error) {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil, false, false, nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, false, false, nil
+	}
+	switch value := v.Interface().(type) {
+	case Nullable:
+		if value.IsNull() {
+			return nil, true, true, nil
+		}
+		return value.NullableValue(), false, true, nil
+	case json.Marshaler:
+		return nil, false, false, nil
+	case driver.Valuer:
+		driverValue, err := value.Value()
+		if err != nil {
+			return nil, false, true, err
+		} else if isSQLNullType(v.Type()) {
+			return driverValue, driverValue == nil, true, nil
+		}
+		// other valuers are only leaves when null, and are otherwise presented as the value itself
+		return nil, driverValue == nil, driverValue == nil, nil
+	}
+	return nil, false, false, nil
+}
+
+// valueIsNull returns whether a value implementing `fragment.Nullable` or `driver.Valuer` is null, and false as the second return value
+// if the value implements neither interface, or if it is a value of another type implementing `driver.Valuer` that is not null.
+func valueIsNull(v reflect.Value) (bool, bool) {
+	_, null, ok, err := nullableValueOf(v)
+	if err != nil {
+		return false, true
+	}
+	return null, ok
+}
+
+// NullableValueTypeMeta returns the type meta of the value wrapped by values of a struct type implementing `fragment.Nullable` or of a `sql.Null*` type,
+// which is the type of the value field of structs such as `sql.NullString`, i.e. structs of a `Valid` field and a value field, and otherwise nil.
+// The second return value is whether the struct type is such a type, so that values of it are presented as their wrapped value by `PickJSON`.
+func NullableValueTypeMeta(structTypeMeta *typemeta.Struct) (typemeta.TypeMeta, bool) {
+	if !isNullableType(structTypeMeta.Type()) {
+		return nil, false
+	} else if structTypeMeta.Type().NumField() == 2 {
+		for i, validIndex := range []int{1, 0} {
+			if validField := structTypeMeta.Fields[validIndex]; validField.Name == "Valid" && validField.Kind() == reflect.Bool {
+				return structTypeMeta.Fields[i].TypeMeta, true
+			}
+		}
+	}
+	return nil, true
+}
+
+// isNullableType returns whether values of the type, or pointers to such values, are treated as nullable leaves presented as their wrapped value,
+// i.e. whether the type implements `fragment.Nullable` or is a `sql.Null*` type
+func isNullableType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Implements(nullableType) || isSQLNullType(t)
+}
+
+// isSQLNullType returns whether the type, or the type pointed to, is one of the `sql.Null*` types, such as `sql.NullString` and `sql.Null[T]`
+func isSQLNullType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath() == "database/sql" && strings.HasPrefix(t.Name(), "Null") && t.Implements(valuerType)
+}
+
+// isValuerType returns whether values of the type, or pointers to such values, may be null by implementing `driver.Valuer` but not `json.Marshaler`
+func isValuerType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Implements(valuerType) && !t.Implements(marshalerType)
+}
+
+var nullableType = reflect.TypeOf((*Nullable)(nil)).Elem()
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
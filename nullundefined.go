@@ -10,12 +10,75 @@ import (
 // In this view, number is zero when it is possible that it was not specified by the user or specified by the user as undefined.
 // In this view, number and boolean values are special, because there is a meaningful difference between `nil`, `0`, and `false`.
 // Therefore, a pointer to `0` or `false` is deemed defined, while a non-pointer value `0` or `false` is deemed undefined.
-// Otherwise, (reflect.Value).IsZero() is used.
+// Values of types implementing `fragment.Nullable` or `driver.Valuer` are deemed undefined when they are null.
+// Otherwise, (reflect.Value).IsZero() is used. Types can override this by implementing `fragment.Undefinable`.
 func IsValueUndefined(v reflect.Value) bool {
-	return isValueUndefined(v, false)
+	return DefaultPolicy.IsValueUndefined(v)
 }
 
-func isValueUndefined(v reflect.Value, elem bool) bool {
+// IsValueJSONNull returns whether a value is deemed to be presented as null in JSON-format in the opinionated view of this package.
+// The following values are considered JSON-null: (1) nil pointers, (2) pointers to JSON-null values, (3) empty strings, (4) slices, arrays, or maps that are empty or only containing JSON-null elements,
+// (5) invalid values, and (6) null values of types implementing `fragment.Nullable` or `driver.Valuer`.
+func IsValueJSONNull(v reflect.Value) bool {
+	return DefaultPolicy.IsValueJSONNull(v)
+}
+
+// IsFieldValueJSONNull returns whether a value is deemed to be presented as null in JSON-format in the opinionated view of this package.
+// The following values are considered JSON-null: (1) nil pointers, (2) pointers to JSON-null values, (3) empty strings, (4) slices, arrays, or maps that are empty or only containing JSON-null elements,
+// (5) invalid values, and (6) null values of types implementing `fragment.Nullable` or `driver.Valuer`.
+func IsFieldValueJSONNull(structField *typemeta.StructField, v reflect.Value) bool {
+	return DefaultPolicy.IsFieldValueJSONNull(structField, v)
+}
+
+// FieldCanBeJSONNull returns whether `IsFieldValueJSONNull` may return true for values of a struct field, which is the case for pointers, interfaces, strings,
+// structs, slices, maps, arrays of such values, and types implementing `fragment.Nullable` or `driver.Valuer`, and for numbers and booleans only if the field
+// has the `omitempty` option.
+func FieldCanBeJSONNull(structField typemeta.StructField) bool {
+	return structField.JSONOmitEmpty || fieldTypeCanBeJSONNull(structField.Type())
+}
+
+// TypeCanBeJSONNull returns whether `PickJSON` may present a value of the type that is not the value of a struct field, such as an element of a slice, as null,
+// i.e. whether it may be a nil pointer, interface, slice, or map, or a null value of a type implementing `fragment.Nullable` or `driver.Valuer`.
+func TypeCanBeJSONNull(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return isNullableType(t) || isValuerType(t)
+}
+
+func fieldTypeCanBeJSONNull(t reflect.Type) bool {
+	if isNullableType(t) || isValuerType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return false
+	case reflect.Array:
+		return t.Len() == 0 || fieldTypeCanBeJSONNull(t.Elem())
+	}
+	return true
+}
+
+// DefaultPolicy is the opinionated policy of this package, which is used by `IsValueUndefined`, `IsValueJSONNull`, and `IsFieldValueJSONNull`,
+// and when no policy is passed to functions accepting one.
+var DefaultPolicy Policy = defaultPolicy{}
+
+type defaultPolicy struct{}
+
+func (p defaultPolicy) IsValueUndefined(v reflect.Value) bool {
+	return p.isValueUndefined(v, false)
+}
+
+func (p defaultPolicy) isValueUndefined(v reflect.Value, elem bool) bool {
+	if undefined, ok := valueIsUndefined(v); ok {
+		return undefined
+	} else if null, ok := valueIsNull(v); ok {
+		return null
+	}
 	switch v.Kind() {
 	case reflect.String, reflect.Struct:
 		if elem {
@@ -26,7 +89,7 @@ func isValueUndefined(v reflect.Value, elem bool) bool {
 		if v.IsNil() {
 			return true
 		}
-		return isValueUndefined(v.Elem(), true)
+		return p.isValueUndefined(v.Elem(), true)
 	case reflect.Bool,
 		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Float32, reflect.Float64,
@@ -42,24 +105,24 @@ func isValueUndefined(v reflect.Value, elem bool) bool {
 	}
 }
 
-// IsValueJSONNull returns whether a value is deemed to be presented as null in JSON-format in the opinionated view of this package.
-// The following values are considered JSON-null: (1) nil pointers, (2) pointers to JSON-null values, (3) empty strings, (4) slices, arrays, or maps that are empty or only containing JSON-null elements,
-// and (5) invalid values.
-func IsValueJSONNull(v reflect.Value) bool {
+func (p defaultPolicy) IsValueJSONNull(v reflect.Value) bool {
+	if null, ok := valueIsNull(v); ok {
+		return null
+	}
 	switch v.Kind() {
 	case reflect.Ptr:
 		if v.IsNil() {
 			return true
 		}
-		return IsValueJSONNull(v.Elem())
+		return p.IsValueJSONNull(v.Elem())
 	case reflect.Invalid:
 		return true
 	case reflect.Slice, reflect.Array:
-		if v.IsNil() {
+		if v.Kind() == reflect.Slice && v.IsNil() {
 			return true
 		}
 		for i := 0; i < v.Len(); i++ {
-			if !IsValueJSONNull(v.Index(i)) {
+			if !p.IsValueJSONNull(v.Index(i)) {
 				return false
 			}
 		}
@@ -69,7 +132,7 @@ func IsValueJSONNull(v reflect.Value) bool {
 			return true
 		}
 		for _, key := range v.MapKeys() {
-			if !IsValueJSONNull(v.MapIndex(key)) {
+			if !p.IsValueJSONNull(v.MapIndex(key)) {
 				return false
 			}
 		}
@@ -84,12 +147,14 @@ func IsValueJSONNull(v reflect.Value) bool {
 	}
 }
 
-// IsFieldValueJSONNull returns whether a value is deemed to be presented as null in JSON-format in the opinionated view of this package.
-// The following values are considered JSON-null: (1) nil pointers, (2) pointers to JSON-null values, (3) empty strings, (4) slices, arrays, or maps that are empty or only containing JSON-null elements,
-// and (5) invalid values.
-func IsFieldValueJSONNull(structField *typemeta.StructField, v reflect.Value) bool {
+func (p defaultPolicy) IsFieldValueJSONNull(structField *typemeta.StructField, v reflect.Value) bool {
 	if structField == nil {
-		return IsValueJSONNull(v)
+		return p.IsValueJSONNull(v)
 	}
-	return IsValueJSONNull(v) || (structField.JSONOmitEmpty && IsValueUndefined(v))
+	return p.IsValueJSONNull(v) || (structField.JSONOmitEmpty && p.IsValueUndefined(v))
+}
+
+// OmitsJSONNullField returns true unless the field is explicitly included, since JSON-null values are only presented when explicitly picked
+func (p defaultPolicy) OmitsJSONNullField(structField *typemeta.StructField, v reflect.Value, explicit bool) bool {
+	return !explicit
 }
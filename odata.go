@@ -0,0 +1,329 @@
+package fragment
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// ParseODataQuery parses the `$select` and `$expand` options of an OData query string, e.g. `$select=Name,Price&$expand=Category($select=Name),Supplier`,
+// into an unstructured fragment, e.g. `{ Name, Price, Category { Name }, Supplier }`. Selected paths such as `Address/City` are parsed as nested fields,
+// and the `$select` and `$expand` options nested within expanded properties are parsed as sub-fragments. Other options are ignored.
+// If `$select` is not specified or is `*`, all properties are selected, which results in an undefined fragment unless properties are expanded,
+// in which case only the expanded properties are included, since an unstructured fragment does not know the other properties.
+func ParseODataQuery(rawQuery string) (Unstructured, error) {
+	options, err := parseODataQuery(rawQuery)
+	if err != nil {
+		return Unstructured{}, err
+	}
+	return options.toUnstructured(), nil
+}
+
+// ParseStructODataQuery parses the `$select` and `$expand` options of an OData query string into a fragment for the specified struct type, see `ParseODataQuery`.
+// Properties are matched against struct field names and JSON names. If `$select` is not specified or is `*`, all fields are included.
+func ParseStructODataQuery(t interface{}, rawQuery string) (Struct, error) {
+	structTypeMeta := typemeta.StructOf(typemeta.Get(t))
+	if structTypeMeta == nil {
+		return Struct{}, errors.New("cannot parse OData query for non-struct type " + typemeta.Get(t).String())
+	}
+	options, err := parseODataQuery(rawQuery)
+	if err != nil {
+		return Struct{typeMeta: structTypeMeta}, err
+	}
+	return options.toStruct(structTypeMeta)
+}
+
+// ODataQuery returns the OData `$select` and `$expand` options selecting the fields of the fragment using their JSON names,
+// e.g. `$select=name,category&$expand=category($select=name)`. Fields with defined fragments are both selected and expanded.
+// If the fragment is undefined, an empty string is returned.
+func (f Struct) ODataQuery() string {
+	return f.odataOptions("&")
+}
+
+func (f Struct) odataOptions(separator string) string {
+	if f.IsUndefined() {
+		return ""
+	}
+	selects := []string{}
+	expands := []string{}
+	f.IterateFields(func(field StructField) {
+		fieldName := field.JSONName
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		selects = append(selects, fieldName)
+		if !field.Fragment.IsUndefined() {
+			expands = append(expands, odataExpandItem(fieldName, field.Fragment.odataOptions(";")))
+		}
+	})
+	return joinODataOptions(selects, expands, separator)
+}
+
+// ODataQuery returns the OData `$select` and `$expand` options selecting the fields of the fragment, with field names sorted,
+// e.g. `$select=Category,Name&$expand=Category($select=Name)`. Fields with defined fragments are both selected and expanded.
+// If the fragment is undefined, an empty string is returned.
+func (f Unstructured) ODataQuery() string {
+	return f.odataOptions("&")
+}
+
+func (f Unstructured) odataOptions(separator string) string {
+	if f.IsUndefined() {
+		return ""
+	}
+	fieldNames := []string{}
+	f.IterateFields(func(fieldName string, fieldFragment Fragment) {
+		fieldNames = append(fieldNames, fieldName)
+	})
+	sort.Strings(fieldNames)
+	expands := []string{}
+	for _, fieldName := range fieldNames {
+		fieldUnstructured, _ := ParseUnstructured(f.Field(fieldName))
+		if !fieldUnstructured.IsUndefined() {
+			expands = append(expands, odataExpandItem(fieldName, fieldUnstructured.odataOptions(";")))
+		}
+	}
+	return joinODataOptions(fieldNames, expands, separator)
+}
+
+// odataQueryOptions is the parsed `$select` and `$expand` options of an OData query or of an expanded property
+type odataQueryOptions struct {
+	// selects is the selected property paths, which is nil if `$select` is not specified
+	selects [][]string
+	expands []odataExpand
+}
+
+type odataExpand struct {
+	path    []string
+	options odataQueryOptions
+}
+
+func parseODataQuery(rawQuery string) (odataQueryOptions, error) {
+	options := odataQueryOptions{}
+	for _, part := range splitODataList(strings.TrimPrefix(rawQuery, "?"), '&') {
+		key, value := part, ""
+		if equalsIndex := strings.Index(part, "="); equalsIndex != -1 {
+			key, value = part[:equalsIndex], part[equalsIndex+1:]
+		}
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return options, err
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return options, NewError(err).Register(key)
+		}
+		if err := options.parseOption(key, value); err != nil {
+			return options, err
+		}
+	}
+	return options, nil
+}
+
+func parseODataOptions(expr string) (odataQueryOptions, error) {
+	options := odataQueryOptions{}
+	for _, part := range splitODataList(expr, ';') {
+		equalsIndex := strings.Index(part, "=")
+		if equalsIndex == -1 {
+			return options, errors.New("invalid option \"" + part + "\"")
+		}
+		if err := options.parseOption(part[:equalsIndex], part[equalsIndex+1:]); err != nil {
+			return options, err
+		}
+	}
+	return options, nil
+}
+
+func (o *odataQueryOptions) parseOption(key string, value string) error {
+	switch strings.TrimSpace(key) {
+	case "$select":
+		if o.selects == nil {
+			o.selects = [][]string{}
+		}
+		for _, item := range splitODataList(value, ',') {
+			path, err := parseODataPath(item)
+			if err != nil {
+				return NewError(err).Register("$select")
+			}
+			o.selects = append(o.selects, path)
+		}
+	case "$expand":
+		for _, item := range splitODataList(value, ',') {
+			expand, err := parseODataExpand(item)
+			if err != nil {
+				return NewError(err).Register("$expand")
+			}
+			o.expands = append(o.expands, expand)
+		}
+	}
+	return nil
+}
+
+func parseODataExpand(item string) (odataExpand, error) {
+	expand := odataExpand{}
+	pathExpr := item
+	if parenIndex := strings.Index(item, "("); parenIndex != -1 {
+		if !strings.HasSuffix(item, ")") {
+			return expand, errors.New("missing closing parenthesis in \"" + item + "\"")
+		}
+		options, err := parseODataOptions(item[parenIndex+1 : len(item)-1])
+		if err != nil {
+			return expand, NewError(err).Register(strings.TrimSpace(item[:parenIndex]))
+		}
+		pathExpr = item[:parenIndex]
+		expand.options = options
+	}
+	path, err := parseODataPath(pathExpr)
+	if err != nil {
+		return expand, err
+	} else if len(path) == 1 && path[0] == "*" {
+		return expand, errors.New("expanding all navigation properties is not supported")
+	}
+	expand.path = path
+	return expand, nil
+}
+
+func parseODataPath(expr string) ([]string, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "*" {
+		return []string{"*"}, nil
+	}
+	path := strings.Split(expr, "/")
+	for _, segment := range path {
+		if segment == "" || strings.ContainsAny(segment, "()*=;, ") {
+			return nil, errors.New("invalid property path \"" + expr + "\"")
+		}
+	}
+	return path, nil
+}
+
+func (o odataQueryOptions) selectsAll() bool {
+	if o.selects == nil {
+		return true
+	}
+	for _, path := range o.selects {
+		if len(path) == 1 && path[0] == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (o odataQueryOptions) toUnstructured() Unstructured {
+	if o.selectsAll() && len(o.expands) == 0 {
+		return NewUnstructured()
+	}
+	fragment := NewEmptyUnstructured()
+	for _, path := range o.selects {
+		if len(path) == 1 && path[0] == "*" {
+			continue
+		}
+		fragment = fragment.Assign(UnstructuredPath{fieldNames: path}.ToFragment())
+	}
+	for _, expand := range o.expands {
+		fragment = fragment.Assign(UnstructuredPath{fieldNames: expand.path, tailFragment: expand.options.toUnstructured()}.ToFragment())
+	}
+	return fragment
+}
+
+func (o odataQueryOptions) toStruct(structTypeMeta *typemeta.Struct) (Struct, error) {
+	if o.selectsAll() && len(o.expands) == 0 {
+		return NewStruct(structTypeMeta), nil
+	}
+	fragment := NewEmptyStruct(structTypeMeta)
+	if o.selectsAll() {
+		fragment = NewStruct(structTypeMeta).EnsureDefined()
+	}
+	for _, path := range o.selects {
+		if len(path) == 1 && path[0] == "*" {
+			continue
+		}
+		pathFragment, err := odataPathToStruct(structTypeMeta, path)
+		if err != nil {
+			return fragment, NewError(err).Register("$select")
+		}
+		fragment = fragment.AssignStruct(pathFragment)
+	}
+	for _, expand := range o.expands {
+		pathFragment, err := odataPathToStruct(structTypeMeta, expand.path, expand.options)
+		if err != nil {
+			return fragment, NewError(err).Register("$expand")
+		}
+		fragment = fragment.AssignStruct(pathFragment)
+	}
+	return fragment, nil
+}
+
+// odataPathToStruct returns a fragment including the field at the property path. If expand options are passed,
+// the fragment of the field at the end of the path is parsed from them.
+func odataPathToStruct(structTypeMeta *typemeta.Struct, path []string, expandOptions ...odataQueryOptions) (Struct, error) {
+	structField := structTypeMeta.FieldByName(path[0])
+	if structField == nil {
+		return Struct{}, errors.New("property \"" + path[0] + "\" does not exist in " + structTypeMeta.String())
+	}
+	fragment := NewEmptyStruct(structTypeMeta)
+	if len(path) == 1 && len(expandOptions) == 0 {
+		return fragment.Add(structField.Index), nil
+	}
+	fieldStructTypeMeta := typemeta.StructOf(structField.TypeMeta)
+	if fieldStructTypeMeta == nil {
+		return fragment, errors.New("cannot select nested properties of non-struct property \"" + path[0] + "\"")
+	}
+	var fieldFragment Struct
+	var err error
+	if len(path) > 1 {
+		fieldFragment, err = odataPathToStruct(fieldStructTypeMeta, path[1:], expandOptions...)
+	} else {
+		fieldFragment, err = expandOptions[0].toStruct(fieldStructTypeMeta)
+	}
+	if err != nil {
+		return fragment, NewError(err).Register(path[0])
+	}
+	return fragment.Set(structField.Index, fieldFragment), nil
+}
+
+// splitODataList splits an expression at the separator, ignoring separators within parentheses
+func splitODataList(expr string, separator rune) []string {
+	items := []string{}
+	depth := 0
+	start := 0
+	for i, char := range expr {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case separator:
+			if depth == 0 {
+				if item := strings.TrimSpace(expr[start:i]); item != "" {
+					items = append(items, item)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if item := strings.TrimSpace(expr[start:]); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+func odataExpandItem(fieldName string, options string) string {
+	if options == "" {
+		return fieldName
+	}
+	return fieldName + "(" + options + ")"
+}
+
+func joinODataOptions(selects []string, expands []string, separator string) string {
+	options := []string{}
+	if len(selects) > 0 {
+		options = append(options, "$select="+strings.Join(selects, ","))
+	}
+	if len(expands) > 0 {
+		options = append(options, "$expand="+strings.Join(expands, ","))
+	}
+	return strings.Join(options, separator)
+}
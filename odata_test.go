@@ -0,0 +1,73 @@
+package fragment
+
+import (
+	"testing"
+)
+
+func TestOData(t *testing.T) {
+	type Supplier struct {
+		Name    string `json:"name"`
+		Country string `json:"country"`
+	}
+	type Category struct {
+		Name      string      `json:"name"`
+		Suppliers []*Supplier `json:"suppliers"`
+	}
+	type Product struct {
+		Name     string    `json:"name"`
+		Price    float64   `json:"price"`
+		Category *Category `json:"category"`
+		Supplier *Supplier `json:"supplier"`
+	}
+	t.Run("parses struct fragments", func(t *testing.T) {
+		fragment, err := ParseStructODataQuery(Product{}, "$select=Name,Price&$expand=Category($select=Name;$expand=Suppliers($select=Country)),Supplier")
+		if err != nil {
+			t.Error("did not expect `ParseStructODataQuery` to return error: " + err.Error())
+			return
+		}
+		if expr := fragment.Expr(); expr != "{ Name, Price, Category { Name, Suppliers { Country } }, Supplier }" {
+			t.Error("unexpected fragment " + expr)
+		}
+		if query := fragment.ODataQuery(); query != "$select=name,price,category,supplier&$expand=category($select=name,suppliers;$expand=suppliers($select=country))" {
+			t.Error("unexpected query " + query)
+		}
+		reparsed, err := ParseStructODataQuery(Product{}, fragment.ODataQuery())
+		if err != nil || reparsed.Expr() != fragment.Expr() {
+			t.Error("expected printed query to be parsed into the same fragment")
+		}
+	})
+	t.Run("selects all fields unless selected", func(t *testing.T) {
+		fragment, err := ParseStructODataQuery(Product{}, "$expand=Supplier($select=Country)")
+		if err != nil {
+			t.Error("did not expect `ParseStructODataQuery` to return error: " + err.Error())
+			return
+		}
+		if expr := fragment.Expr(); expr != "{ Name, Price, Category, Supplier { Country } }" {
+			t.Error("unexpected fragment " + expr)
+		}
+		fragment, err = ParseStructODataQuery(Product{}, "")
+		if err != nil || !fragment.IsUndefined() {
+			t.Error("expected undefined fragment without options")
+		}
+	})
+	t.Run("parses unstructured fragments", func(t *testing.T) {
+		fragment, err := ParseODataQuery("$select=Name,Address/City&$expand=Category($select=Name)&$top=10")
+		if err != nil {
+			t.Error("did not expect `ParseODataQuery` to return error: " + err.Error())
+			return
+		}
+		if !fragment.HasByName("Name") || !fragment.HasPath("Address", "City") || !fragment.HasPath("Category", "Name") || fragment.FieldsLen() != 3 {
+			t.Error("unexpected fragment " + fragment.Expr())
+		}
+		if query := fragment.ODataQuery(); query != "$select=Address,Category,Name&$expand=Address($select=City),Category($select=Name)" {
+			t.Error("unexpected query " + query)
+		}
+	})
+	t.Run("returns errors", func(t *testing.T) {
+		for _, query := range []string{"$select=Weight", "$select=Name/First", "$expand=Category($select=Name", "$expand=*"} {
+			if _, err := ParseStructODataQuery(Product{}, query); err == nil {
+				t.Error("expected `ParseStructODataQuery` to return error for " + query)
+			}
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package fragment
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+const openAPISchemasPrefix = "#/components/schemas/"
+
+// OpenAPI builds OpenAPI 3.1 schemas of the JSON that `MarshalJSON` produces for Go types, where the component schemas describe the JSON
+// of values picked using undefined fragments, and the response schemas of operations describe the JSON of values picked using the fragments
+// of the operations. Component schemas are named by the struct types and referenced as `#/components/schemas/NAME`.
+type OpenAPI struct {
+	shapes *jsonShapeBuilder
+	// full is the shapes of structs with all fields defined, which are used to list the fields that can be selected
+	full *jsonShapeBuilder
+}
+
+// NewOpenAPI returns a new builder of OpenAPI schemas without any component schemas
+func NewOpenAPI() *OpenAPI {
+	full := newJSONShapeBuilder()
+	full.full = true
+	return &OpenAPI{shapes: newJSONShapeBuilder(), full: full}
+}
+
+// ComponentSchema registers the component schema of a struct type, and of the struct types that it references, and returns a reference to it,
+// e.g. `{ "$ref": "#/components/schemas/User" }`. The type is specified as for `ParseStruct`.
+func (o *OpenAPI) ComponentSchema(t interface{}) (map[string]interface{}, error) {
+	structTypeMeta, err := openAPIStructTypeMeta(t)
+	if err != nil {
+		return nil, err
+	}
+	shape, err := o.shapes.definitionShape(structTypeMeta)
+	if err != nil {
+		return nil, err
+	}
+	return shapeJSONSchema(shape, openAPISchemasPrefix), nil
+}
+
+// ResponseSchema returns the schema of the JSON of values picked using the fragment. If the fragment is undefined, a reference to the
+// component schema of the struct type is returned. Structs picked using undefined fragments are registered as component schemas.
+func (o *OpenAPI) ResponseSchema(fragment Struct) (map[string]interface{}, error) {
+	if !fragment.IsValid() {
+		return nil, errors.New("cannot describe JSON of invalid fragment")
+	} else if fragment.IsUndefined() {
+		return o.ComponentSchema(fragment.TypeMeta())
+	}
+	shape, err := o.shapes.structShape(fragment)
+	if err != nil {
+		return nil, err
+	}
+	return shapeJSONSchema(shape, openAPISchemasPrefix), nil
+}
+
+// Response returns a response object with the description and the content of the JSON of values picked using the fragment, e.g.
+// `{ "description": "...", "content": { "application/json": { "schema": { ... } } } }`
+func (o *OpenAPI) Response(fragment Struct, description string) (map[string]interface{}, error) {
+	schema, err := o.ResponseSchema(fragment)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"description": description,
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+	}, nil
+}
+
+// FieldsParameter returns a parameter object of the query parameter of fragment expressions, such as "id, name, profile { bio }", that are parsed
+// for a struct type. The name of the parameter defaults to "fields". The fields that can be selected are the JSON names of the fields of the type,
+// and of nested structs, which are listed as fragment expressions of single fields, e.g. "profile { bio }", in the description and in the
+// `x-fragment-fields` extension, so that each listed value can be used as the value of the parameter.
+func (o *OpenAPI) FieldsParameter(t interface{}, name ...string) (map[string]interface{}, error) {
+	structTypeMeta, err := openAPIStructTypeMeta(t)
+	if err != nil {
+		return nil, err
+	}
+	shape, err := o.full.definitionShape(structTypeMeta)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := "fields"
+	if len(name) > 0 && name[0] != "" {
+		parameterName = name[0]
+	}
+	paths := o.fieldPaths(shape, nil, map[string]bool{})
+	var topLevelFields []string
+	for _, property := range o.full.definitions[shape.ref].properties {
+		topLevelFields = append(topLevelFields, property.name)
+	}
+	return map[string]interface{}{
+		"name":     parameterName,
+		"in":       "query",
+		"required": false,
+		"description": "The fields of the response, as a fragment expression such as \"a, b { c }\". All fields are included if not specified. " +
+			"The fields that can be selected are " + strings.Join(paths, "; ") + ".",
+		"schema":            map[string]interface{}{"type": "string", "examples": []string{strings.Join(topLevelFields, ", ")}},
+		"x-fragment-fields": paths,
+	}, nil
+}
+
+// fieldPathExpr returns the fragment expression that selects the field at a path, e.g. "profile { bio }" for the path of "profile" and "bio"
+func fieldPathExpr(path []string) string {
+	expr := path[len(path)-1]
+	for index := len(path) - 2; index >= 0; index-- {
+		expr = path[index] + " { " + expr + " }"
+	}
+	return expr
+}
+
+// fieldPaths returns the fragment expressions of the fields of the struct of a shape with all fields defined, where the fields of structs that are already
+// on the path are not listed, so that the paths of recursive types are finite
+func (o *OpenAPI) fieldPaths(shape *jsonShape, path []string, onPath map[string]bool) []string {
+	for shape.kind == jsonShapeArray {
+		shape = shape.elem
+	}
+	if shape.kind != jsonShapeRef || onPath[shape.ref] {
+		return nil
+	}
+	onPath[shape.ref] = true
+	defer delete(onPath, shape.ref)
+	var paths []string
+	for _, property := range o.full.definitions[shape.ref].properties {
+		propertyPath := append(append([]string{}, path...), property.name)
+		paths = append(paths, fieldPathExpr(propertyPath))
+		paths = append(paths, o.fieldPaths(property.shape, propertyPath, onPath)...)
+	}
+	return paths
+}
+
+// Components returns the components object of the registered component schemas, i.e. `{ "schemas": { ... } }`
+func (o *OpenAPI) Components() map[string]interface{} {
+	schemas := map[string]interface{}{}
+	for _, name := range o.shapes.definitionNamesSorted() {
+		schemas[name] = shapeJSONSchema(o.shapes.definitions[name], openAPISchemasPrefix)
+	}
+	return map[string]interface{}{"schemas": schemas}
+}
+
+func openAPIStructTypeMeta(t interface{}) (*typemeta.Struct, error) {
+	fragment, err := ParseStruct(t, nil)
+	if err != nil {
+		return nil, err
+	} else if !fragment.IsValid() {
+		return nil, errors.New("cannot describe JSON of non-struct type")
+	}
+	return fragment.TypeMeta(), nil
+}
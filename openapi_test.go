@@ -0,0 +1,73 @@
+package fragment
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	type Profile struct {
+		Bio string `json:"bio"`
+	}
+	type User struct {
+		ID      int64    `json:"id"`
+		Name    string   `json:"name"`
+		Profile *Profile `json:"profile"`
+		Friends []*User  `json:"friends"`
+	}
+	t.Run("builds component and response schemas", func(t *testing.T) {
+		openAPI := NewOpenAPI()
+		f, err := ParseStruct(User{}, "id, friends { name }, profile")
+		if err != nil {
+			t.Error("did not expect `ParseStruct` to return error: " + err.Error())
+			return
+		}
+		response, err := openAPI.Response(f, "The user")
+		if err != nil {
+			t.Error("did not expect `Response` to return error: " + err.Error())
+			return
+		}
+		bytes, _ := json.Marshal(response)
+		expected := `{"content":{"application/json":{"schema":{"additionalProperties":false,"properties":{` +
+			`"friends":{"items":{"additionalProperties":false,"properties":{"name":{"type":["string","null"]}},"required":["name"],"type":["object","null"]},"type":["array","null"]},` +
+			`"id":{"type":"integer"},` +
+			`"profile":{"anyOf":[{"$ref":"#/components/schemas/Profile"},{"type":"null"}]}},` +
+			`"required":["id","profile","friends"],"type":"object"}}},"description":"The user"}`
+		if string(bytes) != expected {
+			t.Error("unexpected response " + string(bytes))
+		}
+		if schema, err := openAPI.ResponseSchema(NewStruct(User{})); err != nil || schema["$ref"] != "#/components/schemas/User" {
+			t.Error("expected response schema of undefined fragment to reference component schema")
+		}
+		bytes, _ = json.Marshal(openAPI.Components())
+		expected = `{"schemas":{"Profile":{"additionalProperties":false,"properties":{"bio":{"type":"string"}},"type":"object"},` +
+			`"User":{"additionalProperties":false,"properties":{` +
+			`"friends":{"items":{"anyOf":[{"$ref":"#/components/schemas/User"},{"type":"null"}]},"type":"array"},` +
+			`"id":{"type":"integer"},"name":{"type":"string"},"profile":{"$ref":"#/components/schemas/Profile"}},"required":["id"],"type":"object"}}}`
+		if string(bytes) != expected {
+			t.Error("unexpected components " + string(bytes))
+		}
+	})
+	t.Run("builds fields parameter", func(t *testing.T) {
+		parameter, err := NewOpenAPI().FieldsParameter(User{})
+		if err != nil {
+			t.Error("did not expect `FieldsParameter` to return error: " + err.Error())
+			return
+		}
+		if paths := strings.Join(parameter["x-fragment-fields"].([]string), ";"); paths != "id;name;profile;profile { bio };friends" {
+			t.Error("unexpected fields " + paths)
+		}
+		for _, path := range parameter["x-fragment-fields"].([]string) {
+			if _, err := ParseStruct(User{}, path); err != nil {
+				t.Error("did not expect `ParseStruct` to return error for listed field \"" + path + "\": " + err.Error())
+			}
+		}
+		if parameter["name"] != "fields" || parameter["in"] != "query" {
+			t.Error("unexpected parameter")
+		}
+		if _, err := NewOpenAPI().FieldsParameter(0); err == nil {
+			t.Error("expected `FieldsParameter` to return error for non-struct type")
+		}
+	})
+}
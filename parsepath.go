@@ -0,0 +1,249 @@
+package fragment
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// ParseStructPath returns a new path for the specified struct type. Each path argument can be a path expression such as `items[0].name`
+// or `tags["en"]`, a list of field names, a field index, a list of field indices, a `fragment.PathSelector`, a `fragment.Path`,
+// or a `fragment.Fragment`, which is set as the tail fragment of the path. Field names can either be struct field names or JSON field names.
+func ParseStructPath(t interface{}, v ...interface{}) (StructPath, error) {
+	builder, err := newStructPathBuilder(t)
+	if err != nil {
+		return StructPath{}, err
+	}
+	for _, v := range v {
+		if err := builder.add(v); err != nil {
+			return StructPath{typeMeta: builder.path.typeMeta}, err
+		}
+	}
+	return builder.build(), nil
+}
+
+type structPathBuilder struct {
+	path      StructPath
+	selectors [][]PathSelector
+	// the struct type meta of the fields that can follow the current position
+	currentTypeMeta *typemeta.Struct
+	// the type meta of the value at the current position
+	currentValueType typemeta.TypeMeta
+}
+
+func newStructPathBuilder(t interface{}) (*structPathBuilder, error) {
+	rootTypeMeta := typemeta.Get(t)
+	typeMeta := typemeta.StructOf(rootTypeMeta)
+	if typeMeta == nil {
+		return nil, errors.New("cannot create struct path for non-struct type " + rootTypeMeta.String())
+	}
+	return &structPathBuilder{
+		path:             StructPath{typeMeta: typeMeta, fieldIndices: []int{}},
+		selectors:        [][]PathSelector{nil},
+		currentTypeMeta:  typeMeta,
+		currentValueType: rootTypeMeta,
+	}, nil
+}
+
+func (b *structPathBuilder) build() StructPath {
+	path := b.path
+	if len(path.fieldIndices) == 0 {
+		path.fieldIndices = nil
+	}
+	path.selectors = compactPathSelectors(b.selectors)
+	return path
+}
+
+func (b *structPathBuilder) add(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		segments, err := parsePathExpr(v)
+		if err != nil {
+			return err
+		}
+		for _, segment := range segments {
+			if segment.name != "" {
+				if err := b.addFieldByName(segment.name); err != nil {
+					return err
+				}
+			}
+			if err := b.addSelectors(segment.selectors...); err != nil {
+				return err
+			}
+		}
+	case []string:
+		for _, fieldName := range v {
+			if err := b.addFieldByName(fieldName); err != nil {
+				return err
+			}
+		}
+	case int:
+		return b.addFieldByIndex(v)
+	case []int:
+		for _, fieldIndex := range v {
+			if err := b.addFieldByIndex(fieldIndex); err != nil {
+				return err
+			}
+		}
+	case PathSelector:
+		return b.addSelectors(v)
+	case []PathSelector:
+		return b.addSelectors(v...)
+	case StructPath:
+		if b.currentTypeMeta == nil || v.typeMeta.Type() != b.currentTypeMeta.Type() {
+			return errors.New("expected struct path for type " + fmt.Sprint(b.currentTypeMeta) + " but received " + v.typeMeta.String())
+		}
+		fieldIndices := v.FieldIndices()
+		return b.addPath(len(fieldIndices), v.Selectors(), func(index int) error {
+			return b.addFieldByIndex(fieldIndices[index])
+		})
+	case Path:
+		fieldNames := v.FieldNames()
+		return b.addPath(len(fieldNames), pathSelectors(v), func(index int) error {
+			return b.addFieldByName(fieldNames[index])
+		})
+	case Fragment:
+		tailFragment, err := ParseStruct(b.path.TailTypeMeta(), v)
+		if err != nil {
+			return err
+		}
+		b.path.tailFragment = tailFragment
+	default:
+		return errors.New("unrecognized path argument " + fmt.Sprint(v))
+	}
+	return nil
+}
+
+func (b *structPathBuilder) addPath(fieldsLen int, selectors [][]PathSelector, addField func(index int) error) error {
+	if len(selectors) > 0 {
+		if err := b.addSelectors(selectors[0]...); err != nil {
+			return err
+		}
+	}
+	for index := 0; index < fieldsLen; index++ {
+		if err := addField(index); err != nil {
+			return err
+		}
+		if index+1 < len(selectors) {
+			if err := b.addSelectors(selectors[index+1]...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *structPathBuilder) addFieldByName(fieldName string) error {
+	if b.currentTypeMeta == nil {
+		return errors.New("cannot select field \"" + fieldName + "\" of non-struct type " + b.currentValueType.String())
+	}
+	structField := b.currentTypeMeta.FieldByName(fieldName)
+	if structField == nil {
+		return errors.New("field with name \"" + fieldName + "\" does not exist in " + b.currentTypeMeta.String())
+	}
+	b.addField(*structField)
+	return nil
+}
+
+// addFieldByJSONName adds the field with the specified JSON name, which excludes fields that are excluded from JSON
+func (b *structPathBuilder) addFieldByJSONName(jsonName string) error {
+	if b.currentTypeMeta == nil {
+		return errors.New("cannot select field \"" + jsonName + "\" of non-struct type " + b.currentValueType.String())
+	}
+	structField := b.currentTypeMeta.FindField(func(structField typemeta.StructField) bool {
+		return structField.JSONName == jsonName
+	})
+	if structField == nil {
+		return errors.New("field with JSON name \"" + jsonName + "\" does not exist in " + b.currentTypeMeta.String())
+	}
+	b.addField(*structField)
+	return nil
+}
+
+func (b *structPathBuilder) addFieldByIndex(fieldIndex int) error {
+	if b.currentTypeMeta == nil {
+		return errors.New("cannot select field [" + fmt.Sprint(fieldIndex) + "] of non-struct type " + b.currentValueType.String())
+	}
+	structField := b.currentTypeMeta.Field(fieldIndex)
+	if structField == nil {
+		return errors.New("field with index [" + fmt.Sprint(fieldIndex) + "] does not exist in " + b.currentTypeMeta.String())
+	}
+	b.addField(*structField)
+	return nil
+}
+
+func (b *structPathBuilder) addField(structField typemeta.StructField) {
+	b.path.fieldIndices = append(b.path.fieldIndices, structField.Index)
+	b.selectors = append(b.selectors, nil)
+	b.currentTypeMeta = typemeta.StructOf(structField.TypeMeta)
+	b.currentValueType = structField.TypeMeta
+}
+
+func (b *structPathBuilder) addSelectors(selectors ...PathSelector) error {
+	for _, selector := range selectors {
+		elemTypeMeta, err := selector.elemTypeMeta(b.currentValueType)
+		if err != nil {
+			return err
+		}
+		last := len(b.selectors) - 1
+		b.selectors[last] = append(b.selectors[last], selector)
+		b.currentValueType = elemTypeMeta
+	}
+	return nil
+}
+
+// ParseUnstructuredPath returns a new unstructured path. Each path argument can be a path expression such as `items[0].name`
+// or `tags["en"]`, a list of field names, a `fragment.PathSelector`, a `fragment.Path`, or a `fragment.Fragment`, which is set as the tail fragment of the path.
+func ParseUnstructuredPath(v ...interface{}) (UnstructuredPath, error) {
+	path := UnstructuredPath{fieldNames: []string{}}
+	selectors := [][]PathSelector{nil}
+	addSelectors := func(positionSelectors ...PathSelector) {
+		last := len(selectors) - 1
+		selectors[last] = append(selectors[last], positionSelectors...)
+	}
+	addFieldName := func(fieldName string) {
+		path.fieldNames = append(path.fieldNames, fieldName)
+		selectors = append(selectors, nil)
+	}
+	for _, v := range v {
+		switch v := v.(type) {
+		case string:
+			segments, err := parsePathExpr(v)
+			if err != nil {
+				return UnstructuredPath{}, err
+			}
+			for _, segment := range segments {
+				if segment.name != "" {
+					addFieldName(segment.name)
+				}
+				addSelectors(segment.selectors...)
+			}
+		case []string:
+			for _, fieldName := range v {
+				addFieldName(fieldName)
+			}
+		case PathSelector:
+			addSelectors(v)
+		case []PathSelector:
+			addSelectors(v...)
+		case Path:
+			vSelectors := pathSelectors(v)
+			if len(vSelectors) > 0 {
+				addSelectors(vSelectors[0]...)
+			}
+			for index, fieldName := range v.FieldNames() {
+				addFieldName(fieldName)
+				if index+1 < len(vSelectors) {
+					addSelectors(vSelectors[index+1]...)
+				}
+			}
+		case Fragment:
+			path.tailFragment = v
+		default:
+			return UnstructuredPath{}, errors.New("unrecognized path argument " + fmt.Sprint(v))
+		}
+	}
+	path.selectors = compactPathSelectors(selectors)
+	return path, nil
+}
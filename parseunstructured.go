@@ -22,7 +22,11 @@ func ParseUnstructured(v interface{}) (Unstructured, error) {
 	} else if fv, ok := v.(Struct); ok {
 		return fv.ToUnstructured(), nil
 	} else if expr, ok := v.(string); ok {
-		return parseString(trimFragmentChars([]rune(expr)))
+		fragmentChars, err := trimFragmentChars([]rune(expr))
+		if err != nil {
+			return Unstructured{}, err
+		}
+		return parseString(fragmentChars)
 	} else if fields, ok := v.([]string); ok {
 		return NewUnstructured().Add(fields...), nil
 	} else if fieldsMap, ok := v.(map[string]interface{}); ok {
@@ -63,7 +67,13 @@ func parseString(fragmentChars []rune) (Unstructured, error) {
 		return fragment, err
 	}
 	for _, fieldPartChars := range fieldParts {
-		fieldPartChars = trimFragmentChars(fieldPartChars)
+		fieldPartChars, err := trimFragmentChars(fieldPartChars)
+		if err != nil && len(fieldParts) > 1 {
+			// blank fields separated by commas are ignored, e.g. the trailing field of "a, "
+			continue
+		} else if err != nil {
+			return fragment, err
+		}
 		fieldPartSpaceParts, err := splitFragment(fieldPartChars, len(fieldPartChars))
 		fieldPartSpacePartsLen := len(fieldPartSpaceParts)
 		if err != nil {
@@ -71,7 +81,10 @@ func parseString(fragmentChars []rune) (Unstructured, error) {
 		} else if fieldPartSpacePartsLen > 2 {
 			return fragment, errors.New("failed parsing \"" + string(fieldPartChars) + "\": contains more than one space")
 		} else {
-			fieldName := trimFragmentChars(fieldPartSpaceParts[0])
+			fieldName, err := trimFragmentChars(fieldPartSpaceParts[0])
+			if err != nil {
+				return fragment, err
+			}
 			if fieldPartSpacePartsLen == 1 {
 				fragment = fragment.Add(string(fieldName))
 			} else if fieldPartSpacePartsLen == 2 {
@@ -171,10 +184,11 @@ const (
 	comma      = rune(',')
 )
 
-func trimFragmentChars(chars []rune) []rune {
+// trimFragmentChars returns the characters without leading and trailing spaces, and an error if the characters are only spaces
+func trimFragmentChars(chars []rune) ([]rune, error) {
 	charsLen := len(chars)
 	if charsLen == 0 {
-		return chars
+		return chars, nil
 	}
 	left := -1
 	for i := 0; i < charsLen; i++ {
@@ -184,6 +198,9 @@ func trimFragmentChars(chars []rune) []rune {
 		left = i
 		break
 	}
+	if left == -1 {
+		return chars, errors.New("unexpected blank fragment \"" + string(chars) + "\"")
+	}
 	right := -1
 	for i := charsLen - 1; i >= left; i-- {
 		if unicode.IsSpace(chars[i]) {
@@ -192,5 +209,5 @@ func trimFragmentChars(chars []rune) []rune {
 		right = i
 		break
 	}
-	return chars[left : right+1]
+	return chars[left : right+1], nil
 }
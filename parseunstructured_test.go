@@ -104,7 +104,7 @@ func TestParseUnstructured(t *testing.T) {
 			return
 		}
 
-		invalidFragments := []string{"fieldA {", "{"}
+		invalidFragments := []string{"fieldA {", "{", " ", "\t\n", "{ }", "fieldA { }", "fieldA { fieldB { \t } }"}
 		for _, invalidFragment := range invalidFragments {
 			fragment, err = ParseUnstructured(invalidFragment)
 			if err == nil {
@@ -113,7 +113,7 @@ func TestParseUnstructured(t *testing.T) {
 			}
 		}
 
-		validFragments := []string{"{ fieldA, fieldB }", "fieldA, ", "field A B C", "fieldA,", "field, A, B, C", "fieldC.fieldD.fieldE.fieldF"}
+		validFragments := []string{"{ fieldA, fieldB }", "fieldA, ", "fieldA, , fieldB", "field A B C", "fieldA,", "field, A, B, C", "fieldC.fieldD.fieldE.fieldF"}
 		for _, validFragment := range validFragments {
 			fragment, err = ParseUnstructured(validFragment)
 			if err != nil {
@@ -122,7 +122,7 @@ func TestParseUnstructured(t *testing.T) {
 			}
 		}
 
-		matches := [][2]string{{"fieldA,fieldB{fieldC}", "{ fieldA, fieldB { fieldC } }"}}
+		matches := [][2]string{{"fieldA,fieldB{fieldC}", "{ fieldA, fieldB { fieldC } }"}, {"c, b { e, d }, a", "{ a, b { d, e }, c }"}}
 		for _, match := range matches {
 			fragment, err = ParseUnstructured(match[0])
 			if err != nil {
@@ -130,7 +130,7 @@ func TestParseUnstructured(t *testing.T) {
 				return
 			}
 			if match[1] != fragment.Expr() {
-				t.Log("expected `Expr` to return \"" + match[1] + "\" for parsed \"" + match[0] + "\", but received \"" + fragment.Expr() + "\"")
+				t.Error("expected `Expr` to return \"" + match[1] + "\" for parsed \"" + match[0] + "\", but received \"" + fragment.Expr() + "\"")
 			}
 		}
 	})
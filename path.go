@@ -19,3 +19,17 @@ type TypedPath interface {
 	FieldIndices() []int
 	TypeMeta() typemeta.TypeMeta
 }
+
+// SelectorPath is the interface for a path with selectors of the elements of the values at the path, which is implemented by `StructPath` and `UnstructuredPath`.
+type SelectorPath interface {
+	Path
+	Selectors() [][]PathSelector
+}
+
+// pathSelectors returns the selectors of a path, which are nil unless the path implements `SelectorPath`
+func pathSelectors(path Path) [][]PathSelector {
+	if selectorPath, ok := path.(SelectorPath); ok {
+		return selectorPath.Selectors()
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package fragment
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// ParseStructPathJSONPointer parses a JSON Pointer (RFC 6901), e.g. `/user/profile/bio` or `/items/0/name`, into a path for the specified struct type.
+// Reference tokens are matched against the JSON field names of structs, the indices of slices and arrays, and the keys of maps.
+func ParseStructPathJSONPointer(t interface{}, pointer string) (StructPath, error) {
+	tokens, err := splitJSONPointer(pointer)
+	if err != nil {
+		return StructPath{}, err
+	}
+	builder, err := newStructPathBuilder(t)
+	if err != nil {
+		return StructPath{}, err
+	}
+	path := builder.path
+	for _, token := range tokens {
+		switch typemeta.NonPtr(builder.currentValueType).(type) {
+		case *typemeta.Slice, *typemeta.Array:
+			index, ok := parseJSONPointerIndex(token)
+			if !ok {
+				return path, errors.New("invalid array index \"" + token + "\" in JSON pointer \"" + pointer + "\"")
+			}
+			err = builder.addSelectors(IndexSelector(index))
+		case *typemeta.Map:
+			err = builder.addSelectors(KeySelector(token))
+		default:
+			err = builder.addFieldByJSONName(token)
+		}
+		if err != nil {
+			return path, errors.New(err.Error() + " in JSON pointer \"" + pointer + "\"")
+		}
+	}
+	return builder.build(), nil
+}
+
+// ParseUnstructuredPathJSONPointer parses a JSON Pointer (RFC 6901), e.g. `/user/profile/bio` or `/items/0/name`, into an unstructured path.
+// Since the path is not typed, reference tokens that are array indices, e.g. `0`, are parsed as index selectors, and all other tokens as field names.
+func ParseUnstructuredPathJSONPointer(pointer string) (UnstructuredPath, error) {
+	tokens, err := splitJSONPointer(pointer)
+	if err != nil {
+		return UnstructuredPath{}, err
+	}
+	v := []interface{}{}
+	for _, token := range tokens {
+		if index, ok := parseJSONPointerIndex(token); ok {
+			v = append(v, IndexSelector(index))
+		} else {
+			v = append(v, []string{token})
+		}
+	}
+	return ParseUnstructuredPath(v...)
+}
+
+// JSONPointer returns the JSON Pointer (RFC 6901) of the path, e.g. `/user/profile/bio`, using JSON field names. An error is returned if the path
+// cannot be expressed as a JSON pointer, which is the case if it contains fields excluded from JSON, wildcard, range, or negative index selectors,
+// or passes a slice, array, or map without selecting a single element.
+func (sp StructPath) JSONPointer() (string, error) {
+	steps, err := sp.jsonPathSteps()
+	if err != nil {
+		return "", err
+	}
+	return jsonPointerOf(steps, sp.Expr())
+}
+
+// JSONPointer returns the JSON Pointer (RFC 6901) of the path, e.g. `/user/profile/bio`. An error is returned if the path contains
+// wildcard, range, or negative index selectors, which cannot be expressed in a JSON pointer.
+func (ip UnstructuredPath) JSONPointer() (string, error) {
+	return jsonPointerOf(ip.jsonPathSteps(), ip.Expr())
+}
+
+// ParseStructPathJSONPath parses a JSONPath expression into a path for the specified struct type. The supported subset is the root `$` followed by
+// member names, e.g. `.name` or `['name']`, and the selectors supported in path expressions, i.e. `[0]`, `[-1]`, `[1:3]`, and `[*]`.
+// Quoted names select struct fields by JSON name, and the keys of maps. Recursive descent (`..`), filters, and unions are not supported.
+func ParseStructPathJSONPath(t interface{}, expr string) (StructPath, error) {
+	segments, err := parseJSONPathExpr(expr)
+	if err != nil {
+		return StructPath{}, err
+	}
+	builder, err := newStructPathBuilder(t)
+	if err != nil {
+		return StructPath{}, err
+	}
+	path := builder.path
+	for _, segment := range segments {
+		if segment.name != "" {
+			if err := builder.addFieldByJSONName(segment.name); err != nil {
+				return path, errors.New(err.Error() + " in JSONPath \"" + expr + "\"")
+			}
+		}
+		for _, selector := range segment.selectors {
+			if _, ok := typemeta.NonPtr(builder.currentValueType).(*typemeta.Struct); ok && selector.Kind == PathSelectorKey {
+				err = builder.addFieldByJSONName(selector.Key)
+			} else {
+				err = builder.addSelectors(selector)
+			}
+			if err != nil {
+				return path, errors.New(err.Error() + " in JSONPath \"" + expr + "\"")
+			}
+		}
+	}
+	return builder.build(), nil
+}
+
+// ParseUnstructuredPathJSONPath parses a JSONPath expression into an unstructured path. See `ParseStructPathJSONPath` for the supported subset.
+// Since the path is not typed, quoted names, e.g. `['name']`, are parsed as field names.
+func ParseUnstructuredPathJSONPath(expr string) (UnstructuredPath, error) {
+	segments, err := parseJSONPathExpr(expr)
+	if err != nil {
+		return UnstructuredPath{}, err
+	}
+	v := []interface{}{}
+	for _, segment := range segments {
+		if segment.name != "" {
+			v = append(v, []string{segment.name})
+		}
+		for _, selector := range segment.selectors {
+			if selector.Kind == PathSelectorKey {
+				v = append(v, []string{selector.Key})
+			} else {
+				v = append(v, selector)
+			}
+		}
+	}
+	return ParseUnstructuredPath(v...)
+}
+
+// JSONPath returns a JSONPath expression for the path, e.g. `$.items[*].name`, using JSON field names. Slices, arrays, and maps
+// passed without selectors are expressed using the wildcard selector. An error is returned if the path contains fields excluded from JSON.
+func (sp StructPath) JSONPath() (string, error) {
+	steps, err := sp.jsonPathSteps()
+	if err != nil {
+		return "", err
+	}
+	return jsonPathOf(steps), nil
+}
+
+// JSONPath returns a JSONPath expression for the path, e.g. `$.items[0].name`.
+func (ip UnstructuredPath) JSONPath() string {
+	return jsonPathOf(ip.jsonPathSteps())
+}
+
+// jsonPathStep is a step of a path in JSON, i.e. a field name or a selector
+type jsonPathStep struct {
+	name     string
+	selector *PathSelector
+}
+
+func (sp StructPath) jsonPathSteps() ([]jsonPathStep, error) {
+	steps := []jsonPathStep{}
+	selectors := sp.Selectors()
+	if len(selectors) > 0 {
+		steps = appendJSONPathSelectorSteps(steps, selectors[0])
+	}
+	currentTypeMeta := sp.typeMeta
+	fieldIndices := sp.FieldIndices()
+	for index, fieldIndex := range fieldIndices {
+		structField := currentTypeMeta.EnsureField(fieldIndex)
+		if structField.JSONName == "" {
+			return nil, errors.New("field \"" + structField.Name + "\" of " + currentTypeMeta.String() + " is excluded from JSON")
+		}
+		steps = append(steps, jsonPathStep{name: structField.JSONName})
+		valueTypeMeta := structField.TypeMeta
+		if index+1 < len(selectors) {
+			steps = appendJSONPathSelectorSteps(steps, selectors[index+1])
+			for _, selector := range selectors[index+1] {
+				elemTypeMeta, err := selector.elemTypeMeta(valueTypeMeta)
+				if err != nil {
+					return nil, err
+				}
+				valueTypeMeta = elemTypeMeta
+			}
+		}
+		if index+1 < len(fieldIndices) {
+			// slices, arrays, and maps passed without selectors are iterated
+			for elemTypeMeta := typemeta.ElemOf(valueTypeMeta); elemTypeMeta != nil; elemTypeMeta = typemeta.ElemOf(valueTypeMeta) {
+				steps = appendJSONPathSelectorSteps(steps, []PathSelector{WildcardSelector()})
+				valueTypeMeta = elemTypeMeta
+			}
+		}
+		currentTypeMeta = typemeta.StructOf(structField.TypeMeta)
+	}
+	return steps, nil
+}
+
+func (ip UnstructuredPath) jsonPathSteps() []jsonPathStep {
+	steps := []jsonPathStep{}
+	selectors := ip.Selectors()
+	if len(selectors) > 0 {
+		steps = appendJSONPathSelectorSteps(steps, selectors[0])
+	}
+	for index, fieldName := range ip.FieldNames() {
+		steps = append(steps, jsonPathStep{name: fieldName})
+		if index+1 < len(selectors) {
+			steps = appendJSONPathSelectorSteps(steps, selectors[index+1])
+		}
+	}
+	return steps
+}
+
+func appendJSONPathSelectorSteps(steps []jsonPathStep, selectors []PathSelector) []jsonPathStep {
+	for index := range selectors {
+		steps = append(steps, jsonPathStep{selector: &selectors[index]})
+	}
+	return steps
+}
+
+func jsonPointerOf(steps []jsonPathStep, expr string) (string, error) {
+	pointer := ""
+	for _, step := range steps {
+		if step.selector == nil {
+			pointer += "/" + escapeJSONPointerToken(step.name)
+			continue
+		}
+		switch step.selector.Kind {
+		case PathSelectorIndex:
+			if step.selector.Index < 0 {
+				return "", errors.New("cannot express negative index " + step.selector.Expr() + " of path \"" + expr + "\" as JSON pointer")
+			}
+			pointer += "/" + strconv.Itoa(step.selector.Index)
+		case PathSelectorKey:
+			pointer += "/" + escapeJSONPointerToken(step.selector.Key)
+		default:
+			return "", errors.New("cannot express selector " + step.selector.Expr() + " of path \"" + expr + "\" as JSON pointer")
+		}
+	}
+	return pointer, nil
+}
+
+func jsonPathOf(steps []jsonPathStep) string {
+	expr := "$"
+	for _, step := range steps {
+		if step.selector == nil {
+			if jsonPathIdentifierRegexp.MatchString(step.name) {
+				expr += "." + step.name
+			} else {
+				expr += "[" + quoteJSONPathName(step.name) + "]"
+			}
+		} else if step.selector.Kind == PathSelectorKey {
+			expr += "[" + quoteJSONPathName(step.selector.Key) + "]"
+		} else {
+			expr += step.selector.Expr()
+		}
+	}
+	return expr
+}
+
+func splitJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	} else if pointer[0] != '/' {
+		return nil, errors.New("JSON pointer \"" + pointer + "\" does not start with \"/\"")
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for index, token := range tokens {
+		tokens[index] = jsonPointerUnescaper.Replace(token)
+	}
+	return tokens, nil
+}
+
+// parseJSONPointerIndex parses an array index as defined by RFC 6901, i.e. "0" or digits without a leading zero
+func parseJSONPointerIndex(token string) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	for _, char := range token {
+		if char < '0' || char > '9' {
+			return 0, false
+		}
+	}
+	index, err := strconv.Atoi(token)
+	return index, err == nil
+}
+
+func escapeJSONPointerToken(token string) string {
+	return jsonPointerEscaper.Replace(token)
+}
+
+func quoteJSONPathName(name string) string {
+	return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(name) + "'"
+}
+
+func parseJSONPathExpr(expr string) ([]pathSegment, error) {
+	trimmed := strings.TrimSpace(expr)
+	if !strings.HasPrefix(trimmed, "$") {
+		return nil, errors.New("JSONPath \"" + expr + "\" does not start with \"$\"")
+	}
+	trimmed = trimmed[1:]
+	if strings.HasPrefix(trimmed, "..") {
+		return nil, errors.New("recursive descent is not supported in JSONPath \"" + expr + "\"")
+	}
+	trimmed = strings.TrimPrefix(trimmed, ".")
+	for _, selector := range unquotedJSONPathSelectors(trimmed) {
+		if !jsonPathSelectorRegexp.MatchString(selector) {
+			return nil, errors.New("unsupported selector [" + selector + "] in JSONPath \"" + expr + "\"")
+		}
+	}
+	segments, err := parsePathExpr(trimmed)
+	if err != nil {
+		return nil, errors.New("invalid JSONPath \"" + expr + "\": " + err.Error())
+	}
+	return segments, nil
+}
+
+// unquotedJSONPathSelectors returns the contents of the bracketed selectors of a JSONPath that are not quoted names, where brackets within
+// quoted names are not treated as selectors
+func unquotedJSONPathSelectors(expr string) []string {
+	var selectors []string
+	var quote rune
+	escaped := false
+	start := -1
+	for index, char := range expr {
+		if quote != 0 {
+			if escaped {
+				escaped = false
+			} else if char == '\\' {
+				escaped = true
+			} else if char == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch char {
+		case '\'', '"':
+			quote = char
+			start = -1
+		case '[':
+			start = index + 1
+		case ']':
+			if start >= 0 {
+				selectors = append(selectors, expr[start:index])
+			}
+			start = -1
+		}
+	}
+	return selectors
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+var jsonPathIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+var jsonPathSelectorRegexp = regexp.MustCompile(`^\s*(\*|-?\d+|-?\d*\s*:\s*-?\d*)\s*$`)
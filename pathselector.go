@@ -0,0 +1,432 @@
+package fragment
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// PathSelectorKind is the kind of a path selector
+type PathSelectorKind int
+
+const (
+	// PathSelectorWildcard selects every element of a slice, array, or map, e.g. `items[*]`
+	PathSelectorWildcard PathSelectorKind = iota
+	// PathSelectorIndex selects the element at an index of a slice or array, e.g. `items[0]`. A negative index counts from the end, e.g. `items[-1]`
+	PathSelectorIndex
+	// PathSelectorSlice selects a range of elements of a slice or array, e.g. `items[1:3]`, `items[:2]`, or `items[-2:]`
+	PathSelectorSlice
+	// PathSelectorKey selects the element of a map with a key, e.g. `tags["en"]`
+	PathSelectorKey
+)
+
+// PathSelector selects one or more elements of a slice, array, or map value at a path.
+// Without selectors, a path iterates every element of the slices, arrays, and maps it passes.
+type PathSelector struct {
+	Kind PathSelectorKind
+	// Index is the index of a `PathSelectorIndex` selector
+	Index int
+	// Start is the (inclusive) start of a `PathSelectorSlice` selector
+	Start int
+	// End is the (exclusive) end of a `PathSelectorSlice` selector, or nil if the range is open
+	End *int
+	// Key is the key of a `PathSelectorKey` selector
+	Key string
+}
+
+// IndexSelector returns a selector for the element at the specified index
+func IndexSelector(index int) PathSelector {
+	return PathSelector{Kind: PathSelectorIndex, Index: index}
+}
+
+// SliceSelector returns a selector for the elements from start up to (but not including) end. If end is omitted, the range is open.
+func SliceSelector(start int, end ...int) PathSelector {
+	selector := PathSelector{Kind: PathSelectorSlice, Start: start}
+	if len(end) > 0 {
+		selector.End = &end[0]
+	}
+	return selector
+}
+
+// KeySelector returns a selector for the map element with the specified key
+func KeySelector(key string) PathSelector {
+	return PathSelector{Kind: PathSelectorKey, Key: key}
+}
+
+// WildcardSelector returns a selector for every element
+func WildcardSelector() PathSelector {
+	return PathSelector{Kind: PathSelectorWildcard}
+}
+
+// Expr returns the expression of the selector, e.g. `[0]`, `[1:3]`, `["en"]`, or `[*]`
+func (ps PathSelector) Expr() string {
+	switch ps.Kind {
+	case PathSelectorIndex:
+		return "[" + strconv.Itoa(ps.Index) + "]"
+	case PathSelectorSlice:
+		expr := "["
+		if ps.Start != 0 {
+			expr += strconv.Itoa(ps.Start)
+		}
+		expr += ":"
+		if ps.End != nil {
+			expr += strconv.Itoa(*ps.End)
+		}
+		return expr + "]"
+	case PathSelectorKey:
+		return "[" + strconv.Quote(ps.Key) + "]"
+	default:
+		return "[*]"
+	}
+}
+
+func (ps PathSelector) String() string {
+	return "PathSelector(" + ps.Expr() + ")"
+}
+
+// elemTypeMeta returns the type meta of the elements selected from a value of the specified type
+func (ps PathSelector) elemTypeMeta(typeMeta typemeta.TypeMeta) (typemeta.TypeMeta, error) {
+	typeMeta = typemeta.NonPtr(typeMeta)
+	switch t := typeMeta.(type) {
+	case *typemeta.Slice:
+		if ps.Kind == PathSelectorKey {
+			return nil, errors.New("cannot select key " + strconv.Quote(ps.Key) + " of slice type " + t.String())
+		}
+		return t.Elem, nil
+	case *typemeta.Array:
+		if ps.Kind == PathSelectorKey {
+			return nil, errors.New("cannot select key " + strconv.Quote(ps.Key) + " of array type " + t.String())
+		}
+		return t.Elem, nil
+	case *typemeta.Map:
+		if ps.Kind == PathSelectorSlice {
+			return nil, errors.New("cannot select range " + ps.Expr() + " of map type " + t.String())
+		}
+		if ps.Kind != PathSelectorWildcard {
+			if _, err := ps.mapKey(t.Key.Type()); err != nil {
+				return nil, err
+			}
+		}
+		return t.Elem, nil
+	default:
+		return nil, errors.New("cannot select " + ps.Expr() + " of non-slice, non-array, and non-map type " + typeMeta.String())
+	}
+}
+
+// mapKey returns the key of a `PathSelectorKey` or `PathSelectorIndex` selector converted to the specified map key type
+func (ps PathSelector) mapKey(keyType reflect.Type) (reflect.Value, error) {
+	var key reflect.Value
+	if ps.Kind == PathSelectorIndex {
+		key = reflect.ValueOf(ps.Index)
+	} else {
+		key = reflect.ValueOf(ps.Key)
+	}
+	if key.Type() == keyType {
+		return key, nil
+	}
+	convertedKey, err := typemeta.ConvertValue(key, keyType)
+	if err != nil {
+		return key, errors.New("invalid key " + ps.Expr() + " for map key type " + keyType.String() + ": " + err.Error())
+	}
+	return convertedKey, nil
+}
+
+// iterateSelectedValues iterates the elements of a slice, array, or map value selected by the selector.
+// The iteratee can return `true` to stop the iteration, in which case true is returned.
+func (ps PathSelector) iterateSelectedValues(value reflect.Value, iteratee func(elemValue reflect.Value) bool) bool {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		valueLen := value.Len()
+		switch ps.Kind {
+		case PathSelectorIndex:
+			index := ps.Index
+			if index < 0 {
+				index += valueLen
+			}
+			if index < 0 || index >= valueLen {
+				return false
+			}
+			return iteratee(value.Index(index))
+		case PathSelectorSlice:
+			start, end := sliceSelectorBounds(ps.Start, ps.End, valueLen)
+			for index := start; index < end; index++ {
+				if iteratee(value.Index(index)) {
+					return true
+				}
+			}
+			return false
+		case PathSelectorWildcard:
+			for index := 0; index < valueLen; index++ {
+				if iteratee(value.Index(index)) {
+					return true
+				}
+			}
+			return false
+		}
+	case reflect.Map:
+		switch ps.Kind {
+		case PathSelectorIndex, PathSelectorKey:
+			key, err := ps.mapKey(value.Type().Key())
+			if err != nil {
+				return false
+			}
+			elemValue := value.MapIndex(key)
+			if !elemValue.IsValid() {
+				return false
+			}
+			return iteratee(elemValue)
+		case PathSelectorWildcard:
+			mapIter := value.MapRange()
+			for mapIter.Next() {
+				if iteratee(mapIter.Value()) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// sliceSelectorBounds returns the bounds of a slice selector for a value with the specified length
+func sliceSelectorBounds(start int, end *int, valueLen int) (int, int) {
+	if start < 0 {
+		start += valueLen
+	}
+	if start < 0 {
+		start = 0
+	} else if start > valueLen {
+		start = valueLen
+	}
+	to := valueLen
+	if end != nil {
+		to = *end
+		if to < 0 {
+			to += valueLen
+		}
+		if to < start {
+			to = start
+		} else if to > valueLen {
+			to = valueLen
+		}
+	}
+	return start, to
+}
+
+// pathSegment is a segment of a path expression, i.e. a field name and the selectors following it
+type pathSegment struct {
+	name      string
+	selectors []PathSelector
+}
+
+// parsePathExpr parses a path expression such as `items[0].name`, `items[-1]`, `items[1:3]`, or `tags["en"]` into segments.
+// The first segment may have an empty name, if the path starts with selectors, e.g. `[0].name`.
+func parsePathExpr(expr string) ([]pathSegment, error) {
+	segments := []pathSegment{}
+	chars := []rune(expr)
+	charsLen := len(chars)
+	current := pathSegment{}
+	nameStart := 0
+	expectName := true
+	for i := 0; i < charsLen; i++ {
+		char := chars[i]
+		switch char {
+		case '.':
+			if expectName {
+				current.name = strings.TrimSpace(string(chars[nameStart:i]))
+			}
+			if current.name == "" && (len(segments) > 0 || len(current.selectors) == 0) {
+				return nil, errors.New("missing field name at index " + strconv.Itoa(i) + " in path \"" + expr + "\"")
+			}
+			segments = append(segments, current)
+			current = pathSegment{}
+			nameStart = i + 1
+			expectName = true
+		case '[':
+			if expectName {
+				current.name = strings.TrimSpace(string(chars[nameStart:i]))
+				expectName = false
+			}
+			end, selector, err := parsePathSelector(chars, i)
+			if err != nil {
+				return nil, errors.New(err.Error() + " in path \"" + expr + "\"")
+			}
+			if len(segments) > 0 && current.name == "" {
+				return nil, errors.New("missing field name at index " + strconv.Itoa(i) + " in path \"" + expr + "\"")
+			}
+			current.selectors = append(current.selectors, selector)
+			i = end
+		case ']':
+			return nil, errors.New("unexpected closing bracket at index " + strconv.Itoa(i) + " in path \"" + expr + "\"")
+		default:
+			if !expectName && !isSpaceRune(char) {
+				return nil, errors.New("unexpected character '" + string(char) + "' at index " + strconv.Itoa(i) + " in path \"" + expr + "\"")
+			}
+		}
+	}
+	if expectName {
+		current.name = strings.TrimSpace(string(chars[nameStart:]))
+	}
+	if current.name == "" {
+		if len(segments) == 0 && len(current.selectors) == 0 {
+			// empty path
+			return segments, nil
+		} else if len(segments) > 0 {
+			return nil, errors.New("missing field name at end of path \"" + expr + "\"")
+		}
+	}
+	return append(segments, current), nil
+}
+
+// unescapeSingleQuoted returns the content of a single-quoted key, where a backslash escapes the following character, such as `\'` and `\\`
+func unescapeSingleQuoted(quoted string) string {
+	var b strings.Builder
+	escaped := false
+	for _, char := range quoted {
+		if char == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(char)
+	}
+	return b.String()
+}
+
+// parsePathSelector parses a selector starting at the opening bracket at the specified index and returns the index of the closing bracket
+func parsePathSelector(chars []rune, start int) (int, PathSelector, error) {
+	charsLen := len(chars)
+	i := start + 1
+	for i < charsLen && isSpaceRune(chars[i]) {
+		i++
+	}
+	if i < charsLen && (chars[i] == '"' || chars[i] == '\'') {
+		quote := chars[i]
+		quoteStart := i
+		i++
+		for i < charsLen && chars[i] != quote {
+			if chars[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= charsLen {
+			return i, PathSelector{}, errors.New("missing closing quote for key at index " + strconv.Itoa(quoteStart))
+		}
+		quoted := string(chars[quoteStart : i+1])
+		var key string
+		if quote == '"' {
+			unquoted, err := strconv.Unquote(quoted)
+			if err != nil {
+				return i, PathSelector{}, errors.New("invalid key " + quoted + " at index " + strconv.Itoa(quoteStart))
+			}
+			key = unquoted
+		} else {
+			key = unescapeSingleQuoted(quoted[1 : len(quoted)-1])
+		}
+		i++
+		for i < charsLen && isSpaceRune(chars[i]) {
+			i++
+		}
+		if i >= charsLen || chars[i] != ']' {
+			return i, PathSelector{}, errors.New("missing closing bracket for selector at index " + strconv.Itoa(start))
+		}
+		return i, KeySelector(key), nil
+	}
+	end := start + 1
+	for end < charsLen && chars[end] != ']' {
+		end++
+	}
+	if end >= charsLen {
+		return end, PathSelector{}, errors.New("missing closing bracket for selector at index " + strconv.Itoa(start))
+	}
+	content := strings.TrimSpace(string(chars[start+1 : end]))
+	selector, err := parsePathSelectorContent(content)
+	if err != nil {
+		return end, selector, errors.New(err.Error() + " at index " + strconv.Itoa(start))
+	}
+	return end, selector, nil
+}
+
+func parsePathSelectorContent(content string) (PathSelector, error) {
+	if content == "" {
+		return PathSelector{}, errors.New("empty selector")
+	} else if content == "*" {
+		return WildcardSelector(), nil
+	}
+	if colon := strings.Index(content, ":"); colon != -1 {
+		startStr := strings.TrimSpace(content[:colon])
+		endStr := strings.TrimSpace(content[colon+1:])
+		selector := PathSelector{Kind: PathSelectorSlice}
+		if startStr != "" {
+			start, err := strconv.Atoi(startStr)
+			if err != nil {
+				return selector, errors.New("invalid range start \"" + startStr + "\"")
+			}
+			selector.Start = start
+		}
+		if endStr != "" {
+			end, err := strconv.Atoi(endStr)
+			if err != nil {
+				return selector, errors.New("invalid range end \"" + endStr + "\"")
+			}
+			selector.End = &end
+		}
+		return selector, nil
+	}
+	if index, err := strconv.Atoi(content); err == nil {
+		return IndexSelector(index), nil
+	}
+	return KeySelector(content), nil
+}
+
+func isSpaceRune(char rune) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r'
+}
+
+// selectorsExpr returns the expression of a list of selectors
+func selectorsExpr(selectors []PathSelector) string {
+	expr := ""
+	for _, selector := range selectors {
+		expr += selector.Expr()
+	}
+	return expr
+}
+
+// pathExpr returns a path expression for the specified names and selectors, where the first list of selectors
+// applies to the root value and the following to the value of the name at the previous index
+func pathExpr(names []string, selectors [][]PathSelector) string {
+	expr := ""
+	if len(selectors) > 0 {
+		expr += selectorsExpr(selectors[0])
+	}
+	for index, name := range names {
+		if expr != "" {
+			expr += "."
+		}
+		expr += name
+		if index+1 < len(selectors) {
+			expr += selectorsExpr(selectors[index+1])
+		}
+	}
+	return expr
+}
+
+// compactPathSelectors returns nil if no selectors are defined
+func compactPathSelectors(selectors [][]PathSelector) [][]PathSelector {
+	for _, positionSelectors := range selectors {
+		if len(positionSelectors) > 0 {
+			return selectors
+		}
+	}
+	return nil
+}
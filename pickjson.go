@@ -9,11 +9,13 @@ import (
 )
 
 // PickJSON returns value to be marshaled using `json.Marshal`, e.g. a `map[string]interface{}`.
-func PickJSON(fragment Fragment, value interface{}) (interface{}, error) {
+// An `Unstructured` fragment picks the fields of maps with string keys, such as decoded JSON objects, and omits the fields that are not in a map.
+// A policy can be passed to determine which values are presented as null, otherwise `DefaultPolicy` is used.
+func PickJSON(fragment Fragment, value interface{}, policy ...Policy) (interface{}, error) {
 	if fragment == nil {
 		return value, nil
 	}
-	reflectValue, err := pickJSON(fragment, reflect.ValueOf(value))
+	reflectValue, err := pickJSON(fragment, reflect.ValueOf(value), policyOf(policy))
 	if !reflectValue.IsValid() {
 		return nil, err
 	}
@@ -21,22 +23,30 @@ func PickJSON(fragment Fragment, value interface{}) (interface{}, error) {
 }
 
 // MarshalJSON uses `PickJSON` to get a value to marshal and then marshals it using `json.Marshal`.
-func MarshalJSON(fragment Fragment, value interface{}) ([]byte, error) {
-	picked, err := PickJSON(fragment, value)
+func MarshalJSON(fragment Fragment, value interface{}, policy ...Policy) ([]byte, error) {
+	picked, err := PickJSON(fragment, value, policy...)
 	if err != nil {
 		return nil, err
 	}
 	return json.Marshal(picked)
 }
 
-func pickJSON(fragment Fragment, reflectValue reflect.Value) (reflect.Value, error) {
+func pickJSON(fragment Fragment, reflectValue reflect.Value, policy Policy) (reflect.Value, error) {
 	var newReflectValue reflect.Value
 	if fragment == nil {
 		return reflectValue, nil
 	}
+	unstructured, isUnstructured := fragment.(Unstructured)
 	if reflectValue.Kind() == reflect.Interface {
 		reflectValue = reflect.ValueOf(reflectValue.Interface())
 	}
+	if nullableValue, null, ok, err := nullableValueOf(reflectValue); ok {
+		// nullable values are leaves presented as their wrapped value
+		if err != nil || null {
+			return newReflectValue, err
+		}
+		return reflect.ValueOf(nullableValue), nil
+	}
 	nonPtrReflectValue := reflectValue
 	if reflectValue.Kind() == reflect.Ptr {
 		if reflectValue.IsNil() {
@@ -47,7 +57,7 @@ func pickJSON(fragment Fragment, reflectValue reflect.Value) (reflect.Value, err
 	if nonPtrReflectValue.Kind() == reflect.Array || nonPtrReflectValue.Kind() == reflect.Slice {
 		jsonSlice := []interface{}{}
 		for index := 0; index < nonPtrReflectValue.Len(); index++ {
-			json, err := pickJSON(fragment, nonPtrReflectValue.Index(index))
+			json, err := pickJSON(fragment, nonPtrReflectValue.Index(index), policy)
 			if err != nil {
 				return newReflectValue, err
 			}
@@ -80,8 +90,8 @@ func pickJSON(fragment Fragment, reflectValue reflect.Value) (reflect.Value, err
 				}
 				field := fragment.Field(index)
 				fieldOriginalValue := nonPtrReflectValue.Field(index)
-				if IsFieldValueJSONNull(&structField, fieldOriginalValue) {
-					if !fragment.IsUndefined() {
+				if policy.IsFieldValueJSONNull(&structField, fieldOriginalValue) {
+					if !policy.OmitsJSONNullField(&structField, fieldOriginalValue, !fragment.IsUndefined()) {
 						// the field was included specifically, so set the value to nil
 						values[field.JSONName] = nil
 					}
@@ -93,12 +103,12 @@ func pickJSON(fragment Fragment, reflectValue reflect.Value) (reflect.Value, err
 				fieldValue := fieldOriginalValue
 				if typemeta.StructOf(field.TypeMeta) != nil || typemeta.InterfaceOf(field.TypeMeta) != nil {
 					var err error
-					fieldValue, err = pickJSON(field.Fragment, fieldOriginalValue)
+					fieldValue, err = pickJSON(field.Fragment, fieldOriginalValue, policy)
 					if err != nil {
 						return reflect.Value{}, NewError(err).Register(structField.Name)
 					}
-					if IsValueJSONNull(fieldValue) {
-						if !fragment.IsUndefined() {
+					if policy.IsValueJSONNull(fieldValue) {
+						if !policy.OmitsJSONNullField(&structField, fieldValue, !fragment.IsUndefined()) {
 							// the field was included specifically, so set the value to nil
 							values[field.JSONName] = nil
 						}
@@ -112,6 +122,28 @@ func pickJSON(fragment Fragment, reflectValue reflect.Value) (reflect.Value, err
 			newReflectValue = nonPtrReflectValue
 		}
 		return newReflectValue, nil
+	} else if isUnstructured && nonPtrReflectValue.Kind() == reflect.Map && nonPtrReflectValue.Type().Key().Kind() == reflect.String {
+		// maps with string keys, such as decoded JSON objects, are picked by the names of the fields, where fields without values are omitted
+		if unstructured.IsUndefined() {
+			return nonPtrReflectValue, nil
+		}
+		values := map[string]interface{}{}
+		for _, fieldName := range unstructured.sortedFieldNames() {
+			fieldValue := nonPtrReflectValue.MapIndex(reflect.ValueOf(fieldName).Convert(nonPtrReflectValue.Type().Key()))
+			if !fieldValue.IsValid() {
+				continue
+			}
+			pickedValue, err := pickJSON(unstructured.fields[fieldName], fieldValue, policy)
+			if err != nil {
+				return reflect.Value{}, NewError(err).Register(fieldName)
+			}
+			if pickedValue.IsValid() {
+				values[fieldName] = pickedValue.Interface()
+			} else {
+				values[fieldName] = nil
+			}
+		}
+		return reflect.ValueOf(values), nil
 	} else if nonPtrReflectValue.Kind() == reflect.Struct {
 		return nonPtrReflectValue, errors.New("not implemented picking structs using unstructured fragments")
 	} else {
@@ -0,0 +1,117 @@
+package fragment
+
+import (
+	"reflect"
+
+	"github.com/ludvigalden/go-typemeta"
+)
+
+// Policy determines which values are deemed to be undefined, and which values are presented as null in JSON-format.
+// It can be passed to `PickJSON`, `MarshalJSON`, `StructPath.SetValueTo`, and `DefinedFragment`. Two policies are built in:
+// `DefaultPolicy`, which is the opinionated view of this package, and `JSONPolicy`, which matches `encoding/json`.
+type Policy interface {
+	// IsValueUndefined returns whether a value is deemed to be undefined, i.e. not specified.
+	IsValueUndefined(v reflect.Value) bool
+	// IsValueJSONNull returns whether a value is presented as null in JSON-format.
+	IsValueJSONNull(v reflect.Value) bool
+	// IsFieldValueJSONNull returns whether the value of a struct field is presented as null in JSON-format. The struct field may be nil.
+	IsFieldValueJSONNull(structField *typemeta.StructField, v reflect.Value) bool
+	// OmitsJSONNullField returns whether a struct field with a JSON-null value, as determined by `IsFieldValueJSONNull`, is omitted rather than presented as null.
+	// The field is explicitly included if the fragment used to pick it is defined.
+	OmitsJSONNullField(structField *typemeta.StructField, v reflect.Value, explicit bool) bool
+}
+
+// Undefinable can be implemented by types to override whether their values are deemed to be undefined by the built-in policies.
+type Undefinable interface {
+	IsUndefined() bool
+}
+
+// JSONPolicy is a policy matching `encoding/json`. Values are deemed undefined when they are empty as defined for the `omitempty` option,
+// i.e. false, 0, nil pointers and interfaces, and empty strings, arrays, slices, and maps. Only nil pointers, interfaces, slices, and maps
+// are presented as null, so that empty slices and maps are presented as `[]` and `{}`. Fields with the `omitempty` option and an empty value are omitted.
+// Like `DefaultPolicy`, null values of types implementing `fragment.Nullable` or `driver.Valuer` are deemed undefined and presented as null.
+var JSONPolicy Policy = jsonPolicy{}
+
+type jsonPolicy struct{}
+
+func (p jsonPolicy) IsValueUndefined(v reflect.Value) bool {
+	if undefined, ok := valueIsUndefined(v); ok {
+		return undefined
+	} else if null, ok := valueIsNull(v); ok {
+		return null
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func (p jsonPolicy) IsValueJSONNull(v reflect.Value) bool {
+	if null, ok := valueIsNull(v); ok {
+		return null
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Interface:
+		return v.IsNil()
+	case reflect.Ptr:
+		if v.IsNil() {
+			return true
+		}
+		return p.IsValueJSONNull(v.Elem())
+	case reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+func (p jsonPolicy) IsFieldValueJSONNull(structField *typemeta.StructField, v reflect.Value) bool {
+	if structField == nil {
+		return p.IsValueJSONNull(v)
+	}
+	return p.IsValueJSONNull(v) || (structField.JSONOmitEmpty && p.IsValueUndefined(v))
+}
+
+// OmitsJSONNullField returns true for fields with the `omitempty` option and an empty value, regardless of whether the field is explicitly included
+func (p jsonPolicy) OmitsJSONNullField(structField *typemeta.StructField, v reflect.Value, explicit bool) bool {
+	return structField != nil && structField.JSONOmitEmpty && p.IsValueUndefined(v)
+}
+
+// valueIsUndefined returns whether a value is undefined as determined by its implementation of `fragment.Undefinable`,
+// and false as the second return value if the value does not implement it.
+func valueIsUndefined(v reflect.Value) (bool, bool) {
+	if !v.IsValid() || !v.CanInterface() {
+		return false, false
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return false, false
+	}
+	if undefinable, ok := v.Interface().(Undefinable); ok {
+		return undefinable.IsUndefined(), true
+	}
+	return false, false
+}
+
+// policyOf returns the first passed policy, or `DefaultPolicy` if none is passed
+func policyOf(policy []Policy) Policy {
+	for _, policy := range policy {
+		if policy != nil {
+			return policy
+		}
+	}
+	return DefaultPolicy
+}
@@ -0,0 +1,166 @@
+package fragment
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type optionalString struct {
+	Value string
+	Set   bool
+}
+
+func (o optionalString) IsUndefined() bool {
+	return !o.Set
+}
+
+func TestPolicy(t *testing.T) {
+	type Item struct {
+		Tags    []string `json:"tags"`
+		Labels  []string `json:"labels"`
+		Count   int      `json:"count,omitempty"`
+		Name    *string  `json:"name"`
+		Comment string   `json:"comment,omitempty"`
+	}
+	item := Item{Tags: []string{}, Count: 0}
+	t.Run("default policy", func(t *testing.T) {
+		marshaled, err := MarshalJSON(NewStruct(Item{}), item)
+		if err != nil {
+			t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+			return
+		}
+		if string(marshaled) != `{}` {
+			t.Error("expected `MarshalJSON` to return `{}`, but received " + string(marshaled))
+		}
+		marshaled, err = MarshalJSON(NewEmptyStruct(Item{}).AddByName("tags", "count"), item)
+		if err != nil {
+			t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+			return
+		}
+		if string(marshaled) != `{"count":null,"tags":null}` {
+			t.Error("expected `MarshalJSON` to return `{\"count\":null,\"tags\":null}`, but received " + string(marshaled))
+		}
+	})
+	t.Run("JSON policy", func(t *testing.T) {
+		marshaled, err := MarshalJSON(NewStruct(Item{}), item, JSONPolicy)
+		if err != nil {
+			t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+			return
+		}
+		if string(marshaled) != `{"labels":null,"name":null,"tags":[]}` {
+			t.Error("expected `MarshalJSON` to return `{\"labels\":null,\"name\":null,\"tags\":[]}`, but received " + string(marshaled))
+		}
+		marshaled, err = MarshalJSON(NewEmptyStruct(Item{}).AddByName("tags", "count"), item, JSONPolicy)
+		if err != nil {
+			t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+			return
+		}
+		if string(marshaled) != `{"tags":[]}` {
+			t.Error("expected `MarshalJSON` to return `{\"tags\":[]}`, but received " + string(marshaled))
+		}
+		if !JSONPolicy.IsValueUndefined(reflect.ValueOf(0)) || JSONPolicy.IsValueUndefined(reflect.ValueOf(struct{}{})) {
+			t.Error("expected `JSONPolicy` to deem empty values undefined, except structs")
+		}
+	})
+	t.Run("undefinable types", func(t *testing.T) {
+		if !IsValueUndefined(reflect.ValueOf(optionalString{Value: "x"})) {
+			t.Error("expected unset optional value to be undefined")
+		}
+		if IsValueUndefined(reflect.ValueOf(optionalString{Set: true})) || JSONPolicy.IsValueUndefined(reflect.ValueOf(optionalString{Set: true})) {
+			t.Error("expected set optional value to be defined")
+		}
+	})
+}
+
+type optionalInt struct {
+	Int   int
+	Valid bool
+}
+
+func (o optionalInt) IsNull() bool {
+	return !o.Valid
+}
+
+func (o optionalInt) NullableValue() interface{} {
+	return o.Int
+}
+
+func TestNullable(t *testing.T) {
+	type Row struct {
+		Name     sql.NullString `json:"name"`
+		Nickname sql.NullString `json:"nickname"`
+		Count    sql.NullInt64  `json:"count"`
+		Deleted  sql.NullTime   `json:"deleted"`
+		Rank     optionalInt    `json:"rank"`
+		Score    *optionalInt   `json:"score"`
+	}
+	row := Row{Name: sql.NullString{String: "Ada", Valid: true}, Count: sql.NullInt64{Int64: 0, Valid: true}, Rank: optionalInt{Int: 3, Valid: true}, Score: &optionalInt{}}
+	marshaled, err := MarshalJSON(NewStruct(Row{}), row)
+	if err != nil {
+		t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+		return
+	}
+	if string(marshaled) != `{"count":0,"name":"Ada","rank":3}` {
+		t.Error("expected `MarshalJSON` to return `{\"count\":0,\"name\":\"Ada\",\"rank\":3}`, but received " + string(marshaled))
+	}
+	marshaled, err = MarshalJSON(NewEmptyStruct(Row{}).AddByName("nickname", "deleted", "score"), row)
+	if err != nil {
+		t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+		return
+	}
+	if string(marshaled) != `{"deleted":null,"nickname":null,"score":null}` {
+		t.Error("expected `MarshalJSON` to return `{\"deleted\":null,\"nickname\":null,\"score\":null}`, but received " + string(marshaled))
+	}
+	if fragment := DefinedFragment(row); fragment.Expr() != "{ Name, Count, Rank }" {
+		t.Error("expected defined fragment `{ Name, Count, Rank }`, but received " + fragment.Expr())
+	}
+}
+
+type jsonbValue map[string]interface{}
+
+func (v jsonbValue) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+type moneyValue struct {
+	Cents int64
+}
+
+func (m moneyValue) Value() (driver.Value, error) {
+	return m.Cents, nil
+}
+
+func TestValuer(t *testing.T) {
+	type Row struct {
+		Data  jsonbValue  `json:"data"`
+		Empty jsonbValue  `json:"empty"`
+		Price moneyValue  `json:"price"`
+		Cost  *moneyValue `json:"cost"`
+	}
+	row := Row{Data: jsonbValue{"a": 1}, Price: moneyValue{Cents: 5}}
+	marshaled, err := MarshalJSON(NewStruct(Row{}), row)
+	if err != nil {
+		t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+		return
+	}
+	if string(marshaled) != `{"data":{"a":1},"price":{"Cents":5}}` {
+		t.Error("expected `MarshalJSON` to return `{\"data\":{\"a\":1},\"price\":{\"Cents\":5}}`, but received " + string(marshaled))
+	}
+	marshaled, err = MarshalJSON(NewEmptyStruct(Row{}).AddByName("empty", "cost"), row)
+	if err != nil {
+		t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+		return
+	}
+	if string(marshaled) != `{"cost":null,"empty":null}` {
+		t.Error("expected `MarshalJSON` to return `{\"cost\":null,\"empty\":null}`, but received " + string(marshaled))
+	}
+	if !IsValueJSONNull(reflect.ValueOf(jsonbValue(nil))) {
+		t.Error("expected nil valuer to be JSON-null")
+	}
+}
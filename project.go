@@ -0,0 +1,174 @@
+package fragment
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Project returns a copy of the value, with the same type, where every field that is not included in the fragment is set to its zero value.
+// Slices, arrays, maps, and pointers are copied and their elements projected, in the same manner as `PickJSON`, and those of fields without fragments,
+// such as `[]string` and `map[string]string`, are copied as well, so that the copy does not share them with the value. Fields that are included
+// with an undefined fragment are projected using the default fragment of their type. Unexported fields are copied as they are.
+func Project(fragment Struct, src interface{}) (interface{}, error) {
+	if src == nil {
+		return nil, nil
+	}
+	projected, err := project(fragment, reflect.ValueOf(src))
+	if err != nil {
+		return src, err
+	}
+	return projected.Interface(), nil
+}
+
+func project(fragment Struct, value reflect.Value) (reflect.Value, error) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value, nil
+		}
+		elem, err := project(fragment, value.Elem())
+		if err != nil {
+			return value, err
+		}
+		projected := reflect.New(value.Type().Elem())
+		projected.Elem().Set(elem)
+		return projected, nil
+	case reflect.Interface:
+		if value.IsNil() {
+			return value, nil
+		}
+		elem, err := project(fragment, value.Elem())
+		if err != nil {
+			return value, err
+		}
+		projected := reflect.New(value.Type()).Elem()
+		projected.Set(elem)
+		return projected, nil
+	case reflect.Slice:
+		if value.IsNil() {
+			return value, nil
+		}
+		projected := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for index := 0; index < value.Len(); index++ {
+			elem, err := project(fragment, value.Index(index))
+			if err != nil {
+				return value, err
+			}
+			projected.Index(index).Set(elem)
+		}
+		return projected, nil
+	case reflect.Array:
+		projected := reflect.New(value.Type()).Elem()
+		for index := 0; index < value.Len(); index++ {
+			elem, err := project(fragment, value.Index(index))
+			if err != nil {
+				return value, err
+			}
+			projected.Index(index).Set(elem)
+		}
+		return projected, nil
+	case reflect.Map:
+		if value.IsNil() {
+			return value, nil
+		}
+		projected := reflect.MakeMapWithSize(value.Type(), value.Len())
+		mapIter := value.MapRange()
+		for mapIter.Next() {
+			elem, err := project(fragment, mapIter.Value())
+			if err != nil {
+				return value, err
+			}
+			projected.SetMapIndex(mapIter.Key(), elem)
+		}
+		return projected, nil
+	case reflect.Struct:
+		structTypeMeta := fragment.TypeMeta()
+		if structTypeMeta == nil || structTypeMeta.Primitive() {
+			return copyValue(value), nil
+		}
+		if value.Type() != structTypeMeta.Type() {
+			return value, errors.New("type of value and fragment do not match: " + value.Type().String() + " vs. " + structTypeMeta.String())
+		}
+		projected := reflect.New(value.Type()).Elem()
+		projected.Set(value)
+		for index := 0; index < value.NumField(); index++ {
+			fieldValue := projected.Field(index)
+			if !fieldValue.CanSet() {
+				continue
+			}
+			if !fragment.HasByIndex(index) {
+				fieldValue.Set(reflect.Zero(fieldValue.Type()))
+				continue
+			}
+			field := fragment.Field(index)
+			if !field.Fragment.IsValid() {
+				fieldValue.Set(copyValue(value.Field(index)))
+				continue
+			}
+			projectedFieldValue, err := project(field.Fragment, value.Field(index))
+			if err != nil {
+				return value, NewError(err).Register(field.Name)
+			}
+			fieldValue.Set(projectedFieldValue)
+		}
+		return projected, nil
+	default:
+		return value, nil
+	}
+}
+
+// copyValue returns a deep copy of a value, where slices, arrays, maps, pointers, and the exported fields of structs are copied
+func copyValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.New(value.Type().Elem())
+		copied.Elem().Set(copyValue(value.Elem()))
+		return copied
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(copyValue(value.Elem()))
+		return copied
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for index := 0; index < value.Len(); index++ {
+			copied.Index(index).Set(copyValue(value.Index(index)))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(value.Type()).Elem()
+		for index := 0; index < value.Len(); index++ {
+			copied.Index(index).Set(copyValue(value.Index(index)))
+		}
+		return copied
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		mapIter := value.MapRange()
+		for mapIter.Next() {
+			copied.SetMapIndex(mapIter.Key(), copyValue(mapIter.Value()))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+		for index := 0; index < value.NumField(); index++ {
+			if copied.Field(index).CanSet() {
+				copied.Field(index).Set(copyValue(value.Field(index)))
+			}
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,96 @@
+package fragment
+
+import (
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	type Profile struct {
+		Bio   string `json:"bio"`
+		Email string `json:"email"`
+	}
+	type User struct {
+		Name     string              `json:"name"`
+		Age      int                 `json:"age"`
+		Profile  *Profile            `json:"profile"`
+		Friends  []User              `json:"friends"`
+		Profiles map[string]*Profile `json:"profiles"`
+	}
+	user := User{
+		Name:     "Ada",
+		Age:      36,
+		Profile:  &Profile{Bio: "bio", Email: "ada@example.com"},
+		Friends:  []User{{Name: "Charles", Age: 44}},
+		Profiles: map[string]*Profile{"work": {Bio: "work bio", Email: "work@example.com"}},
+	}
+	t.Run("zeroes fields outside the fragment", func(t *testing.T) {
+		fragment, err := ParseStruct(User{}, "name, profile { bio }, friends { age }, profiles { email }")
+		if err != nil {
+			t.Error("did not expect `ParseStruct` to return error: " + err.Error())
+			return
+		}
+		projected, err := Project(fragment, &user)
+		if err != nil {
+			t.Error("did not expect `Project` to return error: " + err.Error())
+			return
+		}
+		projectedUser, ok := projected.(*User)
+		if !ok {
+			t.Error("expected `Project` to return a value of the same type")
+			return
+		}
+		if projectedUser == &user || projectedUser.Profile == user.Profile {
+			t.Error("expected `Project` to return a copy")
+			return
+		}
+		if projectedUser.Name != "Ada" || projectedUser.Age != 0 {
+			t.Error("expected immediate fields to be projected")
+		}
+		if projectedUser.Profile.Bio != "bio" || projectedUser.Profile.Email != "" {
+			t.Error("expected pointer fields to be projected")
+		}
+		if len(projectedUser.Friends) != 1 || projectedUser.Friends[0].Name != "" || projectedUser.Friends[0].Age != 44 {
+			t.Error("expected slice elements to be projected")
+		}
+		if work := projectedUser.Profiles["work"]; work == nil || work.Bio != "" || work.Email != "work@example.com" {
+			t.Error("expected map elements to be projected")
+		}
+		if user.Age != 36 || user.Profile.Email != "ada@example.com" {
+			t.Error("expected `Project` to not modify the source value")
+		}
+	})
+	t.Run("keeps everything for undefined fragments", func(t *testing.T) {
+		projected, err := Project(NewStruct(User{}), user)
+		if err != nil {
+			t.Error("did not expect `Project` to return error: " + err.Error())
+			return
+		}
+		if projectedUser := projected.(User); projectedUser.Age != 36 || projectedUser.Profile.Email != "ada@example.com" {
+			t.Error("expected all fields to be kept")
+		}
+	})
+	t.Run("does not share values of fields without fragments", func(t *testing.T) {
+		type Tagged struct {
+			Tags   []string          `json:"tags"`
+			Labels map[string]string `json:"labels"`
+			Scores [2][]int          `json:"scores"`
+		}
+		src := Tagged{Tags: []string{"a"}, Labels: map[string]string{"k": "v"}, Scores: [2][]int{{1}, {2}}}
+		fragment, err := ParseStruct(Tagged{}, "tags, labels, scores")
+		if err != nil {
+			t.Error("did not expect `ParseStruct` to return error: " + err.Error())
+			return
+		}
+		projected, err := Project(fragment, src)
+		if err != nil {
+			t.Error("did not expect `Project` to return error: " + err.Error())
+			return
+		}
+		src.Tags[0] = "x"
+		src.Labels["k"] = "x"
+		src.Scores[0][0] = 0
+		if projectedTagged := projected.(Tagged); projectedTagged.Tags[0] != "a" || projectedTagged.Labels["k"] != "v" || projectedTagged.Scores[0][0] != 1 {
+			t.Error("expected `Project` to copy slices and maps of fields without fragments")
+		}
+	})
+}
@@ -169,3 +169,31 @@ func TestStruct(t *testing.T) {
 		}
 	})
 }
+
+func TestDefinedFragment(t *testing.T) {
+	type Address struct {
+		City   *string `json:"city"`
+		Street *string `json:"street"`
+	}
+	type UserUpdate struct {
+		Name    *string  `json:"name"`
+		Age     *int     `json:"age"`
+		Admin   *bool    `json:"admin"`
+		Address *Address `json:"address"`
+		Note    string   `json:"note"`
+	}
+	name := "Ada"
+	city := "London"
+	zero := 0
+	fragment := DefinedFragment(&UserUpdate{Name: &name, Age: &zero, Address: &Address{City: &city}})
+	if fragment.Expr() != "{ Name, Age, Address { City } }" {
+		t.Error("expected defined fragment `{ Name, Age, Address { City } }`, but received " + fragment.Expr())
+	}
+	fragment = DefinedFragment(UserUpdate{Address: &Address{}})
+	if !fragment.HasByName("Address") || fragment.HasByName("Name") || !fragment.FieldFragmentByName("Address").IsEmpty() {
+		t.Error("expected defined fragment with empty address fragment, but received " + fragment.Expr())
+	}
+	if DefinedFragment("str").IsValid() {
+		t.Error("expected invalid fragment for non-struct value")
+	}
+}
@@ -1,8 +1,6 @@
 package fragment
 
 import (
-	"strings"
-
 	"github.com/ludvigalden/go-typemeta"
 )
 
@@ -10,6 +8,8 @@ import (
 type StructPath struct {
 	typeMeta     *typemeta.Struct
 	fieldIndices []int
+	// selectors of the path, where the first list applies to the root value and the following to the value of the field at the previous index
+	selectors    [][]PathSelector
 	tailFragment Struct
 }
 
@@ -34,6 +34,12 @@ func (sp StructPath) FieldIndices() []int {
 	return sp.fieldIndices
 }
 
+// Selectors returns the selectors of the path, or nil if the path has no selectors. The first list of selectors applies to the root value,
+// and the following lists apply to the value of the field at the previous index, e.g. `[[] [0] []]` for `Items[0].Name`.
+func (sp StructPath) Selectors() [][]PathSelector {
+	return sp.selectors
+}
+
 // TailTypeMeta returns the type meta of the tail of the struct path
 func (sp StructPath) TailTypeMeta() *typemeta.Struct {
 	tailTypeMeta := sp.typeMeta
@@ -113,10 +119,10 @@ func (sp StructPath) ToStructFragment() Struct {
 // Expr returns an expression for the interface path
 func (sp StructPath) Expr() string {
 	fieldNames := sp.FieldNames()
-	if fieldNames == nil {
+	if fieldNames == nil && sp.selectors == nil {
 		return ""
 	}
-	expr := strings.Join(fieldNames, ".")
+	expr := pathExpr(fieldNames, sp.selectors)
 	if !sp.tailFragment.IsUndefined() {
 		tailFragmentExpr := sp.tailFragment.Expr()
 		if tailFragmentExpr != "" {
@@ -129,10 +135,10 @@ func (sp StructPath) Expr() string {
 // JSONExpr returns a JSON expression for the interface path
 func (sp StructPath) JSONExpr() string {
 	jsonFieldNames := sp.JSONFieldNames()
-	if jsonFieldNames == nil {
+	if jsonFieldNames == nil && (sp.fieldIndices != nil || sp.selectors == nil) {
 		return ""
 	}
-	expr := strings.Join(jsonFieldNames, ".")
+	expr := pathExpr(jsonFieldNames, sp.selectors)
 	if !sp.tailFragment.IsUndefined() {
 		tailFragmentJSONExpr := sp.tailFragment.JSONExpr()
 		if tailFragmentJSONExpr != "" {
@@ -1,6 +1,9 @@
 package fragment
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -45,4 +48,219 @@ func TestStructPath(t *testing.T) {
 		}
 
 	})
+	t.Run("selects elements", func(t *testing.T) {
+		type StructD struct {
+			Items []StructA
+			Tags  map[string]string
+			Codes map[int]StructA
+		}
+		value := reflect.ValueOf(StructD{
+			Items: []StructA{{"a"}, {"b"}, {"c"}},
+			Tags:  map[string]string{"en": "hello", "sv": "hej"},
+			Codes: map[int]StructA{404: {"not found"}},
+		})
+		matches := []struct {
+			expr   string
+			values []string
+		}{
+			{"Items.Name", []string{"a", "b", "c"}},
+			{"Items[0].Name", []string{"a"}},
+			{"Items[-1].Name", []string{"c"}},
+			{"Items[1:].Name", []string{"b", "c"}},
+			{"Items[:2].Name", []string{"a", "b"}},
+			{"Items[-2:-1].Name", []string{"b"}},
+			{"Items[*].Name", []string{"a", "b", "c"}},
+			{"Items[3].Name", []string{}},
+			{`Tags["en"]`, []string{"hello"}},
+			{`Tags['sv']`, []string{"hej"}},
+			{"Tags[fi]", []string{}},
+			{"Codes[404].Name", []string{"not found"}},
+		}
+		for _, match := range matches {
+			sp, err := ParseStructPath(StructD{}, match.expr)
+			if err != nil {
+				t.Error("did not expect `ParseStructPath` to return error for " + match.expr + ": " + err.Error())
+				return
+			}
+			values := []string{}
+			sp.IterateValues(value, func(pathValue reflect.Value) {
+				values = append(values, pathValue.String())
+			})
+			if fmt.Sprint(values) != fmt.Sprint(match.values) {
+				t.Error("expected values " + fmt.Sprint(match.values) + " at " + match.expr + ", but received " + fmt.Sprint(values))
+			}
+		}
+		sp := NewStructPath(StructD{}, "Items", IndexSelector(-1), "Name")
+		if sp.Expr() != "Items[-1].Name" {
+			t.Error("expected expression `Items[-1].Name`, but received " + sp.Expr())
+		}
+		var name string
+		if _, err := sp.SetValueTo(value, reflect.ValueOf(&name)); err != nil {
+			t.Error("did not expect `SetValueTo` to return error: " + err.Error())
+		} else if name != "c" {
+			t.Error("expected `SetValueTo` to set \"c\", but set \"" + name + "\"")
+		}
+		var rootName string
+		rootValue := reflect.ValueOf([]StructA{{"x"}, {"y"}})
+		if _, err := NewStructPath([]StructA{}, "[1].Name").SetValueTo(rootValue, reflect.ValueOf(&rootName)); err != nil {
+			t.Error("did not expect `SetValueTo` to return error: " + err.Error())
+		} else if rootName != "y" {
+			t.Error("expected `SetValueTo` to set \"y\", but set \"" + rootName + "\"")
+		}
+		var mismatchedNames []string
+		if _, err := NewStructPath([]StructA{}, "[1].Name").SetValueTo(reflect.ValueOf(StructA{"x"}), reflect.ValueOf(&mismatchedNames)); err != nil {
+			t.Error("did not expect `SetValueTo` to return error: " + err.Error())
+		} else if len(mismatchedNames) != 0 {
+			t.Error("did not expect `SetValueTo` to set values that cannot be selected, but set " + fmt.Sprint(mismatchedNames))
+		}
+		if expr := NewStructPath(StructD{}, `Tags["en"]`).Expr(); expr != `Tags["en"]` {
+			t.Error("expected expression `Tags[\"en\"]`, but received " + expr)
+		}
+		if expr := NewUnstructuedPath("items[1:3].name").Expr(); expr != "items[1:3].name" {
+			t.Error("expected expression `items[1:3].name`, but received " + expr)
+		}
+		if expr := NewUnstructuedPath("[1].name").Expr(); expr != "[1].name" {
+			t.Error("expected expression `[1].name`, but received " + expr)
+		}
+	})
+	t.Run("accepts paths without selectors", func(t *testing.T) {
+		var path Path = struct{ Path }{NewUnstructuedPath("items.name")}
+		if _, ok := path.(SelectorPath); ok {
+			t.Error("did not expect path to implement `SelectorPath`")
+		}
+		up, err := ParseUnstructuredPath(path, "first")
+		if err != nil {
+			t.Error("did not expect `ParseUnstructuredPath` to return error: " + err.Error())
+		} else if up.Expr() != "items.name.first" {
+			t.Error("expected expression `items.name.first`, but received " + up.Expr())
+		}
+	})
+	t.Run("rejects invalid selectors", func(t *testing.T) {
+		for _, expr := range []string{"B[0]", "B.A[\"x\"]", "B.A[0", "B..A", "B.A[0]x"} {
+			if _, err := ParseStructPath(StructC{}, expr); err == nil {
+				t.Error("expected `ParseStructPath` to return error for " + expr)
+			}
+		}
+	})
+}
+
+func TestStructPathJSON(t *testing.T) {
+	type Profile struct {
+		Bio string `json:"bio"`
+	}
+	type User struct {
+		Profile *Profile          `json:"profile"`
+		Tags    map[string]string `json:"tags"`
+		Secret  string            `json:"-"`
+	}
+	type Root struct {
+		User  User   `json:"user"`
+		Items []User `json:"items"`
+	}
+	t.Run("parses and formats JSON pointers", func(t *testing.T) {
+		matches := []struct {
+			pointer string
+			expr    string
+		}{
+			{"/user/profile/bio", "User.Profile.Bio"},
+			{"/items/0/profile", "Items[0].Profile"},
+			{"/user/tags/a~1b~0c", `User.Tags["a/b~c"]`},
+			{"", ""},
+		}
+		for _, match := range matches {
+			sp, err := ParseStructPathJSONPointer(Root{}, match.pointer)
+			if err != nil {
+				t.Error("did not expect `ParseStructPathJSONPointer` to return error for \"" + match.pointer + "\": " + err.Error())
+				continue
+			}
+			if sp.Expr() != match.expr {
+				t.Error("expected expression `" + match.expr + "` for JSON pointer \"" + match.pointer + "\", but received " + sp.Expr())
+			}
+			pointer, err := sp.JSONPointer()
+			if err != nil {
+				t.Error("did not expect `JSONPointer` to return error for " + match.expr + ": " + err.Error())
+			} else if pointer != match.pointer {
+				t.Error("expected JSON pointer \"" + match.pointer + "\", but received \"" + pointer + "\"")
+			}
+		}
+		for _, pointer := range []string{"user", "/items/name", "/items/01", "/user/Secret", "/nope"} {
+			if _, err := ParseStructPathJSONPointer(Root{}, pointer); err == nil {
+				t.Error("expected `ParseStructPathJSONPointer` to return error for \"" + pointer + "\"")
+			}
+		}
+		for _, expr := range []string{"Items.Profile", "Items[-1]", "Items[1:]", "User.Secret"} {
+			if _, err := NewStructPath(Root{}, expr).JSONPointer(); err == nil {
+				t.Error("expected `JSONPointer` to return error for " + expr)
+			}
+		}
+		ip, err := ParseUnstructuredPathJSONPointer("/items/0/name")
+		if err != nil {
+			t.Error("did not expect `ParseUnstructuredPathJSONPointer` to return error: " + err.Error())
+		} else if ip.Expr() != "items[0].name" {
+			t.Error("expected expression `items[0].name`, but received " + ip.Expr())
+		}
+	})
+	t.Run("parses and formats JSONPath", func(t *testing.T) {
+		matches := []struct {
+			jsonPath  string
+			expr      string
+			formatted string
+		}{
+			{"$.items[*].profile.bio", "Items[*].Profile.Bio", "$.items[*].profile.bio"},
+			{"$.items.profile", "Items.Profile", "$.items[*].profile"},
+			{"$['user']['tags']['en']", `User.Tags["en"]`, "$.user.tags['en']"},
+			{"$.items[-1]", "Items[-1]", "$.items[-1]"},
+			{"$.items[1:3]", "Items[1:3]", "$.items[1:3]"},
+		}
+		for _, match := range matches {
+			sp, err := ParseStructPathJSONPath(Root{}, match.jsonPath)
+			if err != nil {
+				t.Error("did not expect `ParseStructPathJSONPath` to return error for \"" + match.jsonPath + "\": " + err.Error())
+				continue
+			}
+			if sp.Expr() != match.expr {
+				t.Error("expected expression `" + match.expr + "` for JSONPath \"" + match.jsonPath + "\", but received " + sp.Expr())
+			}
+			jsonPath, err := sp.JSONPath()
+			if err != nil {
+				t.Error("did not expect `JSONPath` to return error for " + match.expr + ": " + err.Error())
+			} else if jsonPath != match.formatted {
+				t.Error("expected JSONPath \"" + match.formatted + "\", but received \"" + jsonPath + "\"")
+			}
+		}
+		for _, jsonPath := range []string{"items", "$..bio", "$.items[?(@.x)]", "$.items[0,1]"} {
+			if _, err := ParseStructPathJSONPath(Root{}, jsonPath); err == nil {
+				t.Error("expected `ParseStructPathJSONPath` to return error for \"" + jsonPath + "\"")
+			}
+		}
+		if jsonPath := NewUnstructuedPath("items[0].first name").JSONPath(); jsonPath != "$.items[0]['first name']" {
+			t.Error("expected JSONPath \"$.items[0]['first name']\", but received \"" + jsonPath + "\"")
+		}
+		key := `a\b'c`
+		sp := NewStructPath(Root{}, "User.Tags", KeySelector(key))
+		if jsonPath, err := sp.JSONPath(); err != nil {
+			t.Error("did not expect `JSONPath` to return error: " + err.Error())
+		} else if jsonPath != `$.user.tags['a\\b\'c']` {
+			t.Error("expected JSONPath \"$.user.tags['a\\\\b\\'c']\", but received \"" + jsonPath + "\"")
+		} else if parsed, err := ParseStructPathJSONPath(Root{}, jsonPath); err != nil {
+			t.Error("did not expect `ParseStructPathJSONPath` to return error for \"" + jsonPath + "\": " + err.Error())
+		} else if parsed.Expr() != sp.Expr() {
+			t.Error("expected JSONPath \"" + jsonPath + "\" to be parsed as " + sp.Expr() + ", but received " + parsed.Expr())
+		}
+		if up, err := ParseUnstructuredPathJSONPath(`$['a\\b\'c']`); err != nil {
+			t.Error("did not expect `ParseUnstructuredPathJSONPath` to return error: " + err.Error())
+		} else if fieldNames := up.FieldNames(); len(fieldNames) != 1 || fieldNames[0] != key {
+			t.Error("expected field name " + key + ", but received " + strings.Join(fieldNames, "."))
+		} else if jsonPath := up.JSONPath(); jsonPath != `$['a\\b\'c']` {
+			t.Error("expected JSONPath \"$['a\\\\b\\'c']\", but received \"" + jsonPath + "\"")
+		}
+		if up, err := ParseUnstructuredPathJSONPath("$['a[b]'].c[0]"); err != nil {
+			t.Error("did not expect `ParseUnstructuredPathJSONPath` to return error for brackets in quoted name: " + err.Error())
+		} else if jsonPath := up.JSONPath(); jsonPath != "$['a[b]'].c[0]" {
+			t.Error("expected JSONPath \"$['a[b]'].c[0]\", but received \"" + jsonPath + "\"")
+		}
+		if _, err := ParseUnstructuredPathJSONPath("$['a[b]'].c[?(@.x)]"); err == nil {
+			t.Error("expected `ParseUnstructuredPathJSONPath` to return error for unsupported selector after quoted name")
+		}
+	})
 }
@@ -1,10 +1,15 @@
 package fragment
 
-// FragmentAt returns the fragment of the specified fragment at the struct path
+import "github.com/ludvigalden/go-typemeta"
+
+// FragmentAt returns the fragment of the specified fragment at the struct path. If a field of the path is not included in the fragment,
+// an empty fragment is returned.
 func (sp StructPath) FragmentAt(fragment Struct) Struct {
-	currentFragment := fragment
 	for _, fieldIndex := range sp.FieldIndices() {
+		if !fragment.HasByIndex(fieldIndex) {
+			return Struct{typeMeta: typemeta.StructOf(fragment.Field(fieldIndex).TypeMeta), fields: map[int]StructField{}}
+		}
 		fragment = fragment.FieldFragment(fieldIndex)
 	}
-	return currentFragment
+	return fragment
 }
@@ -7,8 +7,11 @@ import (
 	"github.com/ludvigalden/go-typemeta"
 )
 
-// SetValueTo sets
-func (sp StructPath) SetValueTo(value reflect.Value, out reflect.Value) (reflect.Value, error) {
+// SetValueTo sets the values at the path of the specified value to the output value. If the output value is a slice, every non-null value at the path
+// is converted and appended to it, and otherwise the first non-null value is converted and set to it. A policy can be passed to determine
+// which values are null, otherwise `DefaultPolicy` is used.
+func (sp StructPath) SetValueTo(value reflect.Value, out reflect.Value, policy ...Policy) (reflect.Value, error) {
+	valuePolicy := policyOf(policy)
 	nonPtrOut := out
 	for nonPtrOut.Kind() == reflect.Ptr {
 		if nonPtrOut.IsNil() {
@@ -22,7 +25,7 @@ func (sp StructPath) SetValueTo(value reflect.Value, out reflect.Value) (reflect
 		appended := nonPtrOut
 		var err error
 		sp.IterateValues(value, func(pathValue reflect.Value) {
-			if IsValueJSONNull(pathValue) {
+			if valuePolicy.IsValueJSONNull(pathValue) {
 				return
 			}
 			parsedElem, convErr := typemeta.ConvertValue(pathValue, elemType)
@@ -38,7 +41,7 @@ func (sp StructPath) SetValueTo(value reflect.Value, out reflect.Value) (reflect
 		nonPtrOut.Set(appended)
 	default:
 		found := sp.FindValue(value, func(pathValue reflect.Value) bool {
-			return !IsValueJSONNull(pathValue)
+			return !valuePolicy.IsValueJSONNull(pathValue)
 		})
 		if found == nil {
 			return out, nil
@@ -58,7 +61,7 @@ func (sp StructPath) SetValueTo(value reflect.Value, out reflect.Value) (reflect
 // The iteratee can return `true` to stop the iteration (implying that the value has been found), and in that case the value iterated at that point will be returned.
 func (sp StructPath) FindValue(value reflect.Value, iteratee func(pathValue reflect.Value) bool) *reflect.Value {
 	var found *reflect.Value
-	iterateValuesAtPath(value, sp.typeMeta, sp.FieldIndices(), func(structField typemeta.StructField, pathValue reflect.Value) bool {
+	iterateValuesAtPath(value, sp.typeMeta, sp.FieldIndices(), sp.selectors, func(structField typemeta.StructField, pathValue reflect.Value) bool {
 		if iteratee(pathValue) {
 			found = &pathValue
 			return true
@@ -72,7 +75,7 @@ func (sp StructPath) FindValue(value reflect.Value, iteratee func(pathValue refl
 // The iteratee can return `true` to stop the iteration (implying that the value has been found), and in that case the value iterated at that point will be returned.
 func (sp StructPath) FindFieldValue(value reflect.Value, iteratee func(structField typemeta.StructField, pathValue reflect.Value) bool) *reflect.Value {
 	var found *reflect.Value
-	iterateValuesAtPath(value, sp.typeMeta, sp.FieldIndices(), func(structField typemeta.StructField, pathValue reflect.Value) bool {
+	iterateValuesAtPath(value, sp.typeMeta, sp.FieldIndices(), sp.selectors, func(structField typemeta.StructField, pathValue reflect.Value) bool {
 		if iteratee(structField, pathValue) {
 			found = &pathValue
 			return true
@@ -84,7 +87,7 @@ func (sp StructPath) FindFieldValue(value reflect.Value, iteratee func(structFie
 
 // IterateValues iterates all values at the path of the field. The type of the value must equal the type specified for the path.
 func (sp StructPath) IterateValues(value reflect.Value, iteratee func(pathValue reflect.Value)) {
-	iterateValuesAtPath(value, sp.typeMeta, sp.FieldIndices(), func(structField typemeta.StructField, pathValue reflect.Value) bool {
+	iterateValuesAtPath(value, sp.typeMeta, sp.FieldIndices(), sp.selectors, func(structField typemeta.StructField, pathValue reflect.Value) bool {
 		iteratee(pathValue)
 		return false
 	})
@@ -92,17 +95,42 @@ func (sp StructPath) IterateValues(value reflect.Value, iteratee func(pathValue
 
 // IterateFieldValues iterates all values at the path of the field. The type of the value must equal the type specified for the path.
 func (sp StructPath) IterateFieldValues(value reflect.Value, iteratee func(structField typemeta.StructField, pathValue reflect.Value)) {
-	iterateValuesAtPath(value, sp.typeMeta, sp.FieldIndices(), func(structField typemeta.StructField, pathValue reflect.Value) bool {
+	iterateValuesAtPath(value, sp.typeMeta, sp.FieldIndices(), sp.selectors, func(structField typemeta.StructField, pathValue reflect.Value) bool {
 		iteratee(structField, pathValue)
 		return false
 	})
 }
 
-func iterateValuesAtPath(value reflect.Value, typeMeta typemeta.TypeMeta, path []int, iteratee func(structField typemeta.StructField, pathValue reflect.Value) bool) bool {
+// iterateValuesAtPath iterates the values at a path of field indices. Without selectors, every element of the slices, arrays, and maps passed
+// along the path are iterated, and values that cannot be selected by the selectors are skipped. The first list of selectors applies to the specified value and the following to the value of the field at the previous index.
+func iterateValuesAtPath(value reflect.Value, typeMeta typemeta.TypeMeta, path []int, selectors [][]PathSelector, iteratee func(structField typemeta.StructField, pathValue reflect.Value) bool) bool {
+	return iterateFieldValuesAtPath(typemeta.StructField{}, value, typeMeta, path, selectors, iteratee)
+}
+
+func iterateFieldValuesAtPath(structField typemeta.StructField, value reflect.Value, typeMeta typemeta.TypeMeta, path []int, selectors [][]PathSelector, iteratee func(structField typemeta.StructField, pathValue reflect.Value) bool) bool {
+	if len(selectors) > 0 && len(selectors[0]) > 0 {
+		selector := selectors[0][0]
+		elemTypeMeta, err := selector.elemTypeMeta(typeMeta)
+		if err != nil && value.IsValid() {
+			// the type meta of a path is the struct type meta of its root, so root selectors are applied using the type of the value
+			elemTypeMeta, err = selector.elemTypeMeta(typemeta.Get(value.Type()))
+		}
+		if err != nil {
+			// values that cannot be selected by the selector, such as a root value that is not a slice, array, or map, have no values at the path
+			return false
+		}
+		nextSelectors := append([][]PathSelector{selectors[0][1:]}, selectors[1:]...)
+		return selector.iterateSelectedValues(value, func(elemValue reflect.Value) bool {
+			return iterateFieldValuesAtPath(structField, elemValue, elemTypeMeta, path, nextSelectors, iteratee)
+		})
+	}
 	pathLen := len(path)
 	if pathLen == 0 {
-		iteratee(typemeta.StructField{}, value)
-		return false
+		return iteratee(structField, value)
+	}
+	var nextSelectors [][]PathSelector
+	if len(selectors) > 1 {
+		nextSelectors = selectors[1:]
 	}
 	nonPtrValue := value
 	for nonPtrValue.Kind() == reflect.Ptr {
@@ -122,16 +150,9 @@ func iterateValuesAtPath(value reflect.Value, typeMeta typemeta.TypeMeta, path [
 		if structTypeMeta == nil {
 			panic("received struct value for non-struct type")
 		}
-		nextFieldIndex := path[0]
-		nextFieldValue := nonPtrValue.Field(nextFieldIndex)
-		if pathLen > 1 {
-			if iterateValuesAtPath(nextFieldValue, structTypeMeta.Field(nextFieldIndex).TypeMeta, path[1:], iteratee) {
-				return true
-			}
-		} else {
-			if iteratee(structTypeMeta.EnsureField(nextFieldIndex), nextFieldValue) {
-				return true
-			}
+		nextStructField := structTypeMeta.EnsureField(path[0])
+		if iterateFieldValuesAtPath(nextStructField, nonPtrValue.Field(nextStructField.Index), nextStructField.TypeMeta, path[1:], nextSelectors, iteratee) {
+			return true
 		}
 		break
 	case reflect.Slice:
@@ -140,7 +161,7 @@ func iterateValuesAtPath(value reflect.Value, typeMeta typemeta.TypeMeta, path [
 			panic("received slice value for non-slice type")
 		}
 		for index := 0; index < nonPtrValue.Len(); index++ {
-			if iterateValuesAtPath(nonPtrValue.Index(index), sliceTypeMeta.Elem, path, iteratee) {
+			if iterateFieldValuesAtPath(structField, nonPtrValue.Index(index), sliceTypeMeta.Elem, path, selectors, iteratee) {
 				return true
 			}
 		}
@@ -151,7 +172,7 @@ func iterateValuesAtPath(value reflect.Value, typeMeta typemeta.TypeMeta, path [
 			panic("received array value for non-array type")
 		}
 		for index := 0; index < nonPtrValue.Len(); index++ {
-			if iterateValuesAtPath(nonPtrValue.Index(index), arrayTypeMeta.Elem, path, iteratee) {
+			if iterateFieldValuesAtPath(structField, nonPtrValue.Index(index), arrayTypeMeta.Elem, path, selectors, iteratee) {
 				return true
 			}
 		}
@@ -163,7 +184,7 @@ func iterateValuesAtPath(value reflect.Value, typeMeta typemeta.TypeMeta, path [
 		}
 		mapIter := nonPtrValue.MapRange()
 		for mapIter.Next() {
-			if iterateValuesAtPath(mapIter.Value(), mapTypeMeta.Elem, path, iteratee) {
+			if iterateFieldValuesAtPath(structField, mapIter.Value(), mapTypeMeta.Elem, path, selectors, iteratee) {
 				return true
 			}
 		}
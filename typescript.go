@@ -0,0 +1,217 @@
+package fragment
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TypeScriptOptions configures the types returned by `TypeScript`
+type TypeScriptOptions struct {
+	// Name is the name of the type of the fragment. Defaults to the name of the struct type followed by "Fragment".
+	Name string
+	// Interface declares object types as interfaces, e.g. `export interface UserFragment { ... }`, rather than type aliases
+	Interface bool
+	// Pick declares the types of picked structs as `Pick<...>` over full types of the structs, which are declared as well, e.g.
+	// `export type UserFragment = Pick<User, "id" | "name">`. Properties whose types differ from those of the full type, i.e. nested fragments,
+	// are declared alongside the picked properties. The full type of a struct is the JSON presented when all of its fields are defined.
+	Pick bool
+}
+
+// TypeScript returns TypeScript declarations of the JSON that `MarshalJSON` produces for values of the type of the fragment, i.e. the JSON
+// presented by `PickJSON` using `DefaultPolicy`. Properties are named by the JSON names of the fields. Fields that may be JSON-null are typed
+// `T | null` if the fragment is defined, and are optional if it is undefined, as they are then omitted instead. Structs picked using undefined
+// fragments are declared as separate types named by the struct types, so that recursive types can be declared.
+func TypeScript(fragment Struct, options ...TypeScriptOptions) (string, error) {
+	if !fragment.IsValid() {
+		return "", errors.New("cannot declare JSON of invalid fragment")
+	}
+	option := typeScriptOptionsOf(options)
+	if option.Name == "" {
+		option.Name = fragment.TypeMeta().Name() + "Fragment"
+	}
+	w := &typeScriptWriter{options: option, shapes: newJSONShapeBuilder()}
+	if option.Pick {
+		w.shapes.suffix = "Default"
+		w.full = newJSONShapeBuilder()
+		w.full.full = true
+	}
+	shape, err := w.shapes.structShape(fragment)
+	if err != nil {
+		return "", err
+	}
+	declarations := []string{w.declaration(option.Name, shape)}
+	for _, name := range w.shapes.definitionNamesSorted() {
+		declarations = append(declarations, w.declaration(name, w.shapes.definitions[name]))
+	}
+	if w.full != nil {
+		// the full types are declared without picking, and are built when declaring the picked types
+		full := w.full
+		w.full = nil
+		for _, name := range full.definitionNamesSorted() {
+			declarations = append(declarations, w.declaration(name, full.definitions[name]))
+		}
+	}
+	return strings.Join(declarations, "\n"), nil
+}
+
+func typeScriptOptionsOf(options []TypeScriptOptions) TypeScriptOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return TypeScriptOptions{}
+}
+
+// typeScriptWriter writes TypeScript declarations of JSON shapes. If full is set, objects of structs are declared as picked from their full types.
+type typeScriptWriter struct {
+	options TypeScriptOptions
+	shapes  *jsonShapeBuilder
+	full    *jsonShapeBuilder
+}
+
+// declaration returns the declaration of a named type
+func (w *typeScriptWriter) declaration(name string, shape *jsonShape) string {
+	var b strings.Builder
+	b.WriteString(typeScriptComment(shape.description, ""))
+	if w.options.Interface && shape.kind == jsonShapeObject && !shape.nullable {
+		picked, properties := w.pickedProperties(shape)
+		b.WriteString("export interface " + name)
+		if picked != "" {
+			b.WriteString(" extends " + picked)
+		}
+		b.WriteString(" " + w.objectExpr(properties, "") + "\n")
+		return b.String()
+	}
+	b.WriteString("export type " + name + " = " + w.typeExpr(shape, "", false) + ";\n")
+	return b.String()
+}
+
+// typeExpr returns the type expression of a shape, which is parenthesized if it is a union or an intersection and parenthesize is set
+func (w *typeScriptWriter) typeExpr(shape *jsonShape, indent string, parenthesize bool) string {
+	var expr string
+	composite := false
+	switch shape.kind {
+	case jsonShapeObject:
+		picked, properties := w.pickedProperties(shape)
+		if picked == "" {
+			expr = w.objectExpr(properties, indent)
+		} else if len(properties) == 0 {
+			expr = picked
+		} else {
+			expr = picked + " & " + w.objectExpr(properties, indent)
+			composite = true
+		}
+	case jsonShapeMap:
+		expr = "{ [key: string]: " + w.typeExpr(shape.elem, indent, false) + " }"
+	case jsonShapeArray:
+		if shape.length >= 0 {
+			items := make([]string, shape.length)
+			for i := range items {
+				items[i] = w.typeExpr(shape.elem, indent, false)
+			}
+			expr = "[" + strings.Join(items, ", ") + "]"
+		} else {
+			expr = w.typeExpr(shape.elem, indent, true) + "[]"
+		}
+	case jsonShapeString:
+		expr = "string"
+	case jsonShapeInteger, jsonShapeNumber:
+		expr = "number"
+	case jsonShapeBoolean:
+		expr = "boolean"
+	case jsonShapeRef:
+		expr = shape.ref
+	default:
+		expr = "unknown"
+	}
+	if shape.enum != nil {
+		values := make([]string, len(shape.enum))
+		for i, value := range shape.enum {
+			bytes, _ := json.Marshal(value)
+			values[i] = string(bytes)
+		}
+		expr = strings.Join(values, " | ")
+		composite = len(values) > 1
+	}
+	if shape.nullable && expr != "unknown" {
+		if composite {
+			expr = "(" + expr + ")"
+		}
+		expr += " | null"
+		composite = true
+	}
+	if composite && parenthesize {
+		expr = "(" + expr + ")"
+	}
+	return expr
+}
+
+// pickedProperties returns the `Pick<...>` expression of the properties of an object that are the same as those of the full type of its struct,
+// and the remaining properties. If the object is not picked, all properties are returned.
+func (w *typeScriptWriter) pickedProperties(shape *jsonShape) (string, []jsonShapeProperty) {
+	if w.full == nil || shape.typeMeta == nil {
+		return "", shape.properties
+	}
+	fullRef, err := w.full.definitionShape(shape.typeMeta)
+	if err != nil {
+		return "", shape.properties
+	}
+	fullShape := w.full.definitions[fullRef.ref]
+	fullProperties := map[string]jsonShapeProperty{}
+	for _, property := range fullShape.properties {
+		fullProperties[property.name] = property
+	}
+	var keys []string
+	var properties []jsonShapeProperty
+	for _, property := range shape.properties {
+		if fullProperty, ok := fullProperties[property.name]; ok && fullProperty.required == property.required &&
+			w.typeExpr(fullProperty.shape, "", false) == w.typeExpr(property.shape, "", false) {
+			keys = append(keys, strconv.Quote(property.name))
+		} else {
+			properties = append(properties, property)
+		}
+	}
+	if len(keys) == 0 {
+		return "", properties
+	} else if len(keys) == len(fullShape.properties) {
+		return fullRef.ref, properties
+	}
+	return "Pick<" + fullRef.ref + ", " + strings.Join(keys, " | ") + ">", properties
+}
+
+// objectExpr returns the object literal type of properties
+func (w *typeScriptWriter) objectExpr(properties []jsonShapeProperty, indent string) string {
+	if len(properties) == 0 {
+		return "{}"
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, property := range properties {
+		b.WriteString(typeScriptComment(property.shape.description, indent+"  "))
+		b.WriteString(indent + "  " + typeScriptPropertyName(property.name))
+		if !property.required {
+			b.WriteString("?")
+		}
+		b.WriteString(": " + w.typeExpr(property.shape, indent+"  ", false) + ";\n")
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+var typeScriptIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func typeScriptPropertyName(name string) string {
+	if typeScriptIdentifierRegexp.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+func typeScriptComment(description string, indent string) string {
+	if description == "" {
+		return ""
+	}
+	return indent + "/** " + strings.ReplaceAll(description, "*/", "* /") + " */\n"
+}
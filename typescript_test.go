@@ -0,0 +1,105 @@
+package fragment
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+func TestTypeScript(t *testing.T) {
+	type Profile struct {
+		Bio string `json:"bio"`
+		URL string `json:"url"`
+	}
+	type User struct {
+		ID      int64    `json:"id"`
+		Name    string   `json:"name"`
+		Profile *Profile `json:"profile"`
+		Friends []*User  `json:"friends"`
+		Admin   bool     `json:"is-admin,omitempty"`
+	}
+	f, err := ParseStruct(User{}, "id, name, profile { bio }, friends")
+	if err != nil {
+		t.Error("did not expect `ParseStruct` to return error: " + err.Error())
+		return
+	}
+	t.Run("declares types of fragment", func(t *testing.T) {
+		declarations, err := TypeScript(f)
+		if err != nil {
+			t.Error("did not expect `TypeScript` to return error: " + err.Error())
+			return
+		}
+		expected := `export type UserFragment = {
+  id: number;
+  name: string | null;
+  profile: {
+    bio: string | null;
+  } | null;
+  friends: (User | null)[] | null;
+};
+
+export type Profile = {
+  bio?: string;
+  url?: string;
+};
+
+export type User = {
+  id: number;
+  name?: string;
+  profile?: Profile;
+  friends?: (User | null)[];
+  "is-admin"?: boolean;
+};
+`
+		if declarations != expected {
+			t.Error("unexpected declarations " + declarations)
+		}
+	})
+	t.Run("declares types picked from full types", func(t *testing.T) {
+		declarations, err := TypeScript(f.Omit("friends"), TypeScriptOptions{Name: "UserSummary", Pick: true, Interface: true})
+		if err != nil {
+			t.Error("did not expect `TypeScript` to return error: " + err.Error())
+			return
+		}
+		expected := `export interface UserSummary extends Pick<User, "id" | "name"> {
+  profile: Pick<Profile, "bio"> | null;
+}
+
+export interface Profile {
+  bio: string | null;
+  url: string | null;
+}
+
+export interface User {
+  id: number;
+  name: string | null;
+  profile: Profile | null;
+  friends: (User | null)[] | null;
+  "is-admin": boolean | null;
+}
+`
+		if declarations != expected {
+			t.Error("unexpected declarations " + declarations)
+		}
+	})
+	t.Run("declares values marshaled by their own methods", func(t *testing.T) {
+		type Event struct {
+			Payload json.RawMessage `json:"payload"`
+			Address net.IP          `json:"address"`
+		}
+		declarations, err := TypeScript(NewStruct(Event{}).AddByName("payload", "address"))
+		if err != nil {
+			t.Error("did not expect `TypeScript` to return error: " + err.Error())
+			return
+		}
+		expected := "export type EventFragment = {\n  payload: unknown;\n  address: string | null;\n};\n"
+		if declarations != expected {
+			t.Error("unexpected declarations " + declarations)
+		}
+	})
+	t.Run("returns error for invalid fragment", func(t *testing.T) {
+		if _, err := TypeScript(Struct{}); err == nil {
+			t.Error("expected `TypeScript` to return error")
+		}
+	})
+}
@@ -1,5 +1,7 @@
 package fragment
 
+import "sort"
+
 // Unstructured is an interface for a fragment, not specific to any type
 type Unstructured struct {
 	fields map[string]Fragment
@@ -308,13 +310,15 @@ func (f Unstructured) Primitive() bool {
 	return false
 }
 
-// Expr returns the fragment string for the fragment
+// Expr returns the fragment string for the fragment, where the fields are sorted by name, so that fragments of the same fields have the same
+// expression regardless of the order in which the fields were added
 func (f Unstructured) Expr() string {
 	if f.IsUndefined() {
 		return ""
 	}
 	expr := ""
-	for fieldName, fieldFragment := range f.fields {
+	for _, fieldName := range f.sortedFieldNames() {
+		fieldFragment := f.fields[fieldName]
 		if expr != "" {
 			expr += ", "
 		}
@@ -332,6 +336,16 @@ func (f Unstructured) Expr() string {
 	return "{ " + expr + " }"
 }
 
+// sortedFieldNames returns the names of the fields of the fragment sorted by name
+func (f Unstructured) sortedFieldNames() []string {
+	fieldNames := make([]string, 0, len(f.fields))
+	for fieldName := range f.fields {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+	return fieldNames
+}
+
 // JSONExpr returns the unstructured fragment expression, since it contains no JSON metadata.
 func (f Unstructured) JSONExpr() string {
 	return f.Expr()
@@ -1,6 +1,7 @@
 package fragment
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -60,4 +61,20 @@ func TestUnstructured(t *testing.T) {
 			return
 		}
 	})
+	t.Run("picks JSON values", func(t *testing.T) {
+		var value interface{}
+		if err := json.Unmarshal([]byte(`[{"id": 1, "secret": "x", "user": {"name": "A", "email": "a@b"}}, {"id": 2, "user": null}, null]`), &value); err != nil {
+			t.Error("did not expect `Unmarshal` to return error: " + err.Error())
+			return
+		}
+		fragment, _ := ParseUnstructured("id, user { name }")
+		bytes, err := MarshalJSON(fragment, value)
+		if err != nil {
+			t.Error("did not expect `MarshalJSON` to return error: " + err.Error())
+			return
+		}
+		if picked := string(bytes); picked != `[{"id":1,"user":{"name":"A"}},{"id":2,"user":null},null]` {
+			t.Error("unexpected picked JSON " + picked)
+		}
+	})
 }
@@ -1,10 +1,10 @@
 package fragment
 
-import "strings"
-
 // UnstructuredPath is an interface path
 type UnstructuredPath struct {
-	fieldNames   []string
+	fieldNames []string
+	// selectors of the path, where the first list applies to the root value and the following to the value of the field at the previous index
+	selectors    [][]PathSelector
 	tailFragment Fragment
 }
 
@@ -23,6 +23,12 @@ func (ip UnstructuredPath) FieldNames() []string {
 	return ip.fieldNames
 }
 
+// Selectors returns the selectors of the path, or nil if the path has no selectors. The first list of selectors applies to the root value,
+// and the following lists apply to the value of the field at the previous index.
+func (ip UnstructuredPath) Selectors() [][]PathSelector {
+	return ip.selectors
+}
+
 // JSONFieldNames returns the head fragment
 func (ip UnstructuredPath) JSONFieldNames() []string {
 	return ip.FieldNames()
@@ -34,31 +40,27 @@ func (ip UnstructuredPath) ToFragment() Fragment {
 	if fieldNames == nil {
 		return nil
 	}
-	root := NewUnstructured()
-	current := root
-	maxIndex := len(fieldNames) - 1
-	for i, key := range fieldNames {
-		if i == maxIndex {
-			if ip.tailFragment != nil && !ip.tailFragment.IsUndefined() {
-				current = current.Set(key, ip.tailFragment)
-			} else {
-				current = current.Add(key)
-			}
+	var current Fragment
+	if ip.tailFragment != nil && !ip.tailFragment.IsUndefined() {
+		current = ip.tailFragment
+	}
+	for i := len(fieldNames) - 1; i >= 0; i-- {
+		if current == nil {
+			current = NewEmptyUnstructured().Add(fieldNames[i])
 		} else {
-			current = current.Set(key, Unstructured{})
-			current = current.Field(key).(Unstructured)
+			current = NewEmptyUnstructured().Set(fieldNames[i], current)
 		}
 	}
-	return root
+	return current
 }
 
 // Expr returns an expression for the interface path
 func (ip UnstructuredPath) Expr() string {
 	fieldNames := ip.FieldNames()
-	if fieldNames == nil {
+	if fieldNames == nil && ip.selectors == nil {
 		return ""
 	}
-	expr := strings.Join(fieldNames, ".")
+	expr := pathExpr(fieldNames, ip.selectors)
 	if ip.tailFragment != nil {
 		tailFragmentExpr := ip.tailFragment.Expr()
 		if tailFragmentExpr != "" {
@@ -71,10 +73,10 @@ func (ip UnstructuredPath) Expr() string {
 // JSONExpr returns a JSON expression for the interface path
 func (ip UnstructuredPath) JSONExpr() string {
 	jsonFieldNames := ip.JSONFieldNames()
-	if jsonFieldNames == nil {
+	if jsonFieldNames == nil && ip.selectors == nil {
 		return ""
 	}
-	expr := strings.Join(jsonFieldNames, ".")
+	expr := pathExpr(jsonFieldNames, ip.selectors)
 	if ip.tailFragment != nil {
 		tailFragmentJSONExpr := ip.tailFragment.JSONExpr()
 		if tailFragmentJSONExpr != "" {
@@ -0,0 +1,27 @@
+package fragment
+
+import (
+	"testing"
+)
+
+func TestUnstructuredPath(t *testing.T) {
+	t.Run("converts to fragment", func(t *testing.T) {
+		if fragment := NewUnstructuedPath("a").ToFragment(); fragment.Expr() != "{ a }" {
+			t.Error("expected fragment `{ a }`, but received " + fragment.Expr())
+		}
+		if fragment := NewUnstructuedPath("a.b.c").ToFragment(); fragment.Expr() != "{ a { b { c } } }" {
+			t.Error("expected fragment `{ a { b { c } } }`, but received " + fragment.Expr())
+		}
+		tailFragment, err := ParseUnstructured("x { y }")
+		if err != nil {
+			t.Error("did not expect `ParseUnstructured` to return error: " + err.Error())
+			return
+		}
+		up, err := ParseUnstructuredPath("a.b", tailFragment)
+		if err != nil {
+			t.Error("did not expect `ParseUnstructuredPath` to return error: " + err.Error())
+		} else if fragment := up.ToFragment(); fragment.Expr() != "{ a { b { x { y } } } }" {
+			t.Error("expected fragment `{ a { b { x { y } } } }`, but received " + fragment.Expr())
+		}
+	})
+}